@@ -1,3 +1,8 @@
 module github.com/wundergraph/client-go
 
-go 1.18
+go 1.21
+
+require (
+	golang.org/x/sync v0.10.0
+	golang.org/x/time v0.10.0
+)
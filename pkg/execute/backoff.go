@@ -0,0 +1,47 @@
+package execute
+
+import (
+	"math/rand"
+	"time"
+)
+
+// defaultBackoff is used by WithRetry and WithAutoReconnect if backoff is nil
+var defaultBackoff = ExponentialBackoffWithJitter(100*time.Millisecond, 10*time.Second)
+
+// ConstantBackoff waits d before every retry
+func ConstantBackoff(d time.Duration) BackoffFunc {
+	return func(attempt int) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff waits base before the first retry and doubles the wait for every further retry, up to max
+func ExponentialBackoff(base, max time.Duration) BackoffFunc {
+	return func(attempt int) time.Duration {
+		return exponential(base, max, attempt)
+	}
+}
+
+// ExponentialBackoffWithJitter waits a random duration between 0 and the wait of ExponentialBackoff,
+// so that many clients failing at the same time, e.g. during an outage of the server, don't retry in lockstep.
+// It's the recommended BackoffFunc for WithRetry and WithAutoReconnect.
+func ExponentialBackoffWithJitter(base, max time.Duration) BackoffFunc {
+	return func(attempt int) time.Duration {
+		d := exponential(base, max, attempt)
+		if d <= 0 {
+			return 0
+		}
+		return time.Duration(rand.Int63n(int64(d) + 1))
+	}
+}
+
+func exponential(base, max time.Duration, attempt int) time.Duration {
+	d := base
+	for i := 1; i < attempt && d < max; i++ {
+		if d > max/2 {
+			return max
+		}
+		d *= 2
+	}
+	return min(d, max)
+}
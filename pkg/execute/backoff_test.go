@@ -0,0 +1,61 @@
+package execute_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+func TestBackoff(t *testing.T) {
+	const (
+		base = 100 * time.Millisecond
+		max  = time.Second
+	)
+	constant := execute.ConstantBackoff(base)
+	exponential := execute.ExponentialBackoff(base, max)
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: 100 * time.Millisecond},
+		{attempt: 2, want: 200 * time.Millisecond},
+		{attempt: 3, want: 400 * time.Millisecond},
+		{attempt: 4, want: 800 * time.Millisecond},
+		{attempt: 5, want: max},
+		{attempt: 100, want: max},
+	}
+	for _, tt := range tests {
+		if got := constant(tt.attempt); got != base {
+			t.Errorf("ConstantBackoff: attempt %d: expected %v, got %v", tt.attempt, base, got)
+		}
+		if got := exponential(tt.attempt); got != tt.want {
+			t.Errorf("ExponentialBackoff: attempt %d: expected %v, got %v", tt.attempt, tt.want, got)
+		}
+	}
+}
+
+func TestExponentialBackoffWithJitter(t *testing.T) {
+	const (
+		base = 100 * time.Millisecond
+		max  = time.Second
+	)
+	jitter := execute.ExponentialBackoffWithJitter(base, max)
+	exponential := execute.ExponentialBackoff(base, max)
+	for _, attempt := range []int{1, 2, 3, 4, 5, 100} {
+		waits := make(map[time.Duration]bool)
+		for i := 0; i < 100; i++ {
+			d := jitter(attempt)
+			if d < 0 || d > exponential(attempt) {
+				t.Fatalf("attempt %d: expected a wait between 0 and %v, got %v", attempt, exponential(attempt), d)
+			}
+			waits[d] = true
+		}
+		if len(waits) < 2 {
+			t.Errorf("attempt %d: expected random waits, got %v", attempt, waits)
+		}
+	}
+	if d := execute.ExponentialBackoffWithJitter(0, max)(1); d != 0 {
+		t.Errorf("expected no wait with a base of 0, got %v", d)
+	}
+}
@@ -0,0 +1,105 @@
+package execute
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// BatchRequest is a single query of a Batch
+type BatchRequest struct {
+	// Path is the path of the operation, e.g. "/operations/Users"
+	Path  string
+	Input any
+}
+
+// BatchResponse is the result of a single query of a Batch.
+// Err is a *GraphQLError if the query failed, Data might still carry partial data in that case.
+type BatchResponse struct {
+	Data       json.RawMessage
+	Extensions json.RawMessage
+	Err        error
+}
+
+// batchOperation is the JSON representation of a BatchRequest
+type batchOperation struct {
+	Operation string          `json:"operation"`
+	Variables json.RawMessage `json:"variables,omitempty"`
+}
+
+// Batch sends several queries as a JSON array in a single POST request to the batch endpoint at path.
+// The server responds with an array of responses, which are returned in the order of requests.
+// The returned error is only set if the batch as a whole failed, errors of single queries are reported by BatchResponse.Err.
+func Batch(client *http.Client, ctx context.Context, baseURL, path string, requests []BatchRequest, opts ...Option) ([]BatchResponse, error) {
+	return batch(New(client, baseURL), ctx, path, requests, newOptions(opts))
+}
+
+// Batch is like the package level Batch
+func (c *Client) Batch(ctx context.Context, path string, requests []BatchRequest, opts ...Option) ([]BatchResponse, error) {
+	return batch(c, ctx, path, requests, c.options(opts))
+}
+
+func batch(c *Client, ctx context.Context, path string, requests []BatchRequest, o *options) ([]BatchResponse, error) {
+	baseUrlWithPath, err := c.operationURL(o, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	operations := make([]batchOperation, len(requests))
+	for i, request := range requests {
+		operations[i].Operation = request.Path
+		if err := o.validateInput(request.Input); err != nil {
+			return nil, fmt.Errorf("%s: %w", request.Path, err)
+		}
+		if hasInput(request.Input) {
+			variables, err := o.encodeInput(request.Input)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %w", ErrEncodingInput, request.Path, err)
+			}
+			operations[i].Variables = variables
+		}
+	}
+	body, err := o.codec.Marshal(operations)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEncodingInput, err)
+	}
+	return doRequest(c, ctx, o, OperationQuery, path, true, "", o.newRequestID(), func(ctx context.Context) (*http.Request, error) {
+		req, err := o.newRequest(ctx, "POST", baseUrlWithPath, body)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(operationTypeHeader, "query")
+		return req, nil
+	}, func(res *http.Response, o *options) ([]BatchResponse, error) {
+		return decodeBatch(res, o, len(requests))
+	})
+}
+
+func decodeBatch(res *http.Response, o *options, n int) ([]BatchResponse, error) {
+	if !isSuccess(res.StatusCode) {
+		return nil, newAPIError(res, o.errorCodes)
+	}
+	defer res.Body.Close()
+	var envelopes []responseEnvelope[json.RawMessage]
+	if err := newDecoder(o.codec, responseBody(res, o), o.strictDecoding).Decode(&envelopes); err != nil {
+		if errors.Is(err, ErrResponseTooLarge) {
+			return nil, ErrResponseTooLarge
+		}
+		return nil, fmt.Errorf("error decoding batch response: %w", err)
+	}
+	if len(envelopes) != n {
+		return nil, fmt.Errorf("batch response has %d entries, expected %d", len(envelopes), n)
+	}
+	responses := make([]BatchResponse, n)
+	for i, envelope := range envelopes {
+		if envelope.Data != nil {
+			responses[i].Data = *envelope.Data
+		}
+		responses[i].Extensions = envelope.Extensions
+		if len(envelope.Errors) != 0 {
+			responses[i].Err = newGraphQLError(envelope.Errors, o.errorCodes)
+		}
+	}
+	return responses, nil
+}
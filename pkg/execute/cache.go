@@ -0,0 +1,158 @@
+package execute
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores Query responses for WithCache, implementations must be safe for concurrent use
+type Cache interface {
+	Get(ctx context.Context, key string) (*CacheEntry, bool)
+	Set(ctx context.Context, key string, entry *CacheEntry)
+}
+
+// CacheEntry is a cached Query response
+type CacheEntry struct {
+	ETag    string
+	Header  http.Header
+	Body    []byte
+	Expires time.Time
+}
+
+// WithCache caches Query responses in cache, keyed by their URL.
+// Responses are served from the cache without a request until the max-age of their Cache-Control header expires,
+// afterwards they're revalidated with If-None-Match if the server sent an ETag.
+// Queries sent as POST and responses carrying GraphQL errors aren't cached. As the key doesn't include headers, all callers should use the same credentials.
+func WithCache(cache Cache) Option {
+	return func(o *options) {
+		o.cache = cache
+	}
+}
+
+// NewMemoryCache returns a Cache which keeps entries in memory
+func NewMemoryCache() Cache {
+	return &memoryCache{
+		entries: map[string]*CacheEntry{},
+	}
+}
+
+type memoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]*CacheEntry
+}
+
+func (c *memoryCache) Get(_ context.Context, key string) (*CacheEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.entries[key]
+	return entry, ok
+}
+
+func (c *memoryCache) Set(_ context.Context, key string, entry *CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+}
+
+func (e *CacheEntry) response() *http.Response {
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Header:     e.Header.Clone(),
+		Body:       io.NopCloser(bytes.NewReader(e.Body)),
+	}
+}
+
+// sendCached serves the request from the cache or revalidates the cached entry, newRequest is passed to send.
+// fromCache reports whether the response is the cached entry. Responses carrying GraphQL errors aren't cached.
+func sendCached(ctx context.Context, o *options, key string, newRequest func() (*http.Request, error), send func(func() (*http.Request, error)) (*http.Response, error)) (res *http.Response, fromCache bool, err error) {
+	cache := o.cache
+	entry, cached := cache.Get(ctx, key)
+	if cached && time.Now().Before(entry.Expires) {
+		return entry.response(), true, nil
+	}
+	res, err = send(func() (*http.Request, error) {
+		req, err := newRequest()
+		if err == nil && cached && entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		return req, err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	switch {
+	case res.StatusCode == http.StatusNotModified && cached:
+		_ = res.Body.Close()
+		revalidated := *entry
+		if maxAge, ok := cacheMaxAge(res.Header); ok {
+			revalidated.Expires = time.Now().Add(maxAge)
+		}
+		cache.Set(ctx, key, &revalidated)
+		return revalidated.response(), true, nil
+	case res.StatusCode == http.StatusOK:
+		etag := res.Header.Get("ETag")
+		maxAge, ok := cacheMaxAge(res.Header)
+		if etag == "" && !ok {
+			return res, false, nil
+		}
+		defer res.Body.Close()
+		// the limit of WithMaxResponseBytes applies before the response is buffered
+		body, err := io.ReadAll(responseBody(res, o))
+		if err != nil {
+			return nil, false, err
+		}
+		if hasGraphQLErrors(body) {
+			uncached := *res
+			uncached.Body = io.NopCloser(bytes.NewReader(body))
+			return &uncached, false, nil
+		}
+		entry := &CacheEntry{
+			ETag:    etag,
+			Header:  res.Header,
+			Body:    body,
+			Expires: time.Now().Add(maxAge),
+		}
+		cache.Set(ctx, key, entry)
+		return entry.response(), false, nil
+	}
+	return res, false, nil
+}
+
+// hasGraphQLErrors reports whether body is a response envelope carrying errors
+func hasGraphQLErrors(body []byte) bool {
+	var envelope struct {
+		Errors []json.RawMessage `json:"errors"`
+	}
+	return json.Unmarshal(body, &envelope) == nil && len(envelope.Errors) != 0
+}
+
+// cacheMaxAge returns the max-age of the Cache-Control header, ok is false if the response must not be cached
+func cacheMaxAge(header http.Header) (maxAge time.Duration, ok bool) {
+	cacheControl := header.Get("Cache-Control")
+	if cacheControl == "" {
+		return 0, false
+	}
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store":
+			return 0, false
+		case "no-cache":
+			maxAge, ok = 0, true
+		case "max-age":
+			seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+			if err == nil && seconds > 0 {
+				maxAge, ok = time.Duration(seconds)*time.Second, true
+			}
+		}
+	}
+	return maxAge, ok
+}
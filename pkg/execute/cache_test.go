@@ -0,0 +1,63 @@
+package execute_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+func newCacheServer(t *testing.T, body string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestCacheServesFreshResponses(t *testing.T) {
+	srv, requests := newCacheServer(t, `{"data":{"id":1}}`)
+	c := execute.New(srv.Client(), srv.URL, execute.WithCache(execute.NewMemoryCache()))
+	for i := 0; i < 2; i++ {
+		var response map[string]any
+		if err := c.Query(context.Background(), "/operations/User", nil, &response); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("expected 1 request, got %d", n)
+	}
+}
+
+func TestCacheSkipsResponsesWithErrors(t *testing.T) {
+	srv, requests := newCacheServer(t, `{"data":null,"errors":[{"message":"not found"}]}`)
+	c := execute.New(srv.Client(), srv.URL, execute.WithCache(execute.NewMemoryCache()))
+	for i := 0; i < 2; i++ {
+		var response map[string]any
+		var gqlErr *execute.GraphQLError
+		if err := c.Query(context.Background(), "/operations/User", nil, &response); !errors.As(err, &gqlErr) {
+			t.Fatalf("expected a GraphQLError, got %v", err)
+		}
+	}
+	if n := requests.Load(); n != 2 {
+		t.Fatalf("expected 2 requests, got %d", n)
+	}
+}
+
+func TestCacheAppliesMaxResponseBytes(t *testing.T) {
+	srv, _ := newCacheServer(t, `{"data":{"name":"`+strings.Repeat("a", 1024)+`"}}`)
+	c := execute.New(srv.Client(), srv.URL, execute.WithCache(execute.NewMemoryCache()), execute.WithMaxResponseBytes(100))
+	var response map[string]any
+	if err := c.Query(context.Background(), "/operations/User", nil, &response); !errors.Is(err, execute.ErrResponseTooLarge) {
+		t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+	}
+}
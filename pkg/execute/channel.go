@@ -0,0 +1,134 @@
+package execute
+
+import (
+	"context"
+)
+
+// ChannelOverflow decides what Stream.Channel does when the consumer doesn't keep up and the buffer is full
+type ChannelOverflow int
+
+const (
+	// ChannelOverflowBlock stops reading from the connection until the consumer catches up,
+	// the server eventually blocks too once the flow control window is used up
+	ChannelOverflowBlock ChannelOverflow = iota
+	// ChannelOverflowDropOldest discards the oldest buffered message, which suits live queries where only the latest state matters
+	ChannelOverflowDropOldest
+	// ChannelOverflowError ends the stream with ErrChannelFull, so that no message is silently lost
+	ChannelOverflowError
+)
+
+// WithChannelBuffer buffers up to n messages in the channel returned by Stream.Channel and sets the policy for a full buffer.
+// Without it, the channel is unbuffered and blocks. The other policies buffer at least one message.
+func WithChannelBuffer(n int, overflow ChannelOverflow) Option {
+	return func(o *options) {
+		o.channelBuffer = n
+		o.channelOverflow = overflow
+	}
+}
+
+// Channel pumps the messages of the stream into the returned channels from a background goroutine.
+// Errors that don't end the stream, e.g. *GraphQLError or ErrReconnected, are delivered on the error channel too.
+// It buffers a single error, further errors follow the policy of WithChannelBuffer: ChannelOverflowBlock stops reading
+// until the consumer takes the error, so consumers must drain both channels, ChannelOverflowDropOldest replaces the
+// undelivered error and ChannelOverflowError ends the stream with ErrChannelFull.
+// Both channels are closed, and the stream is closed, once the stream ends or ctx is canceled.
+// Calling Next while the channel API is in use is not allowed.
+func (s *Stream[Response]) Channel(ctx context.Context) (<-chan *Response, <-chan error) {
+	size := s.channelBuffer
+	if s.channelOverflow != ChannelOverflowBlock && size < 1 {
+		size = 1
+	}
+	messages := make(chan *Response, size)
+	errs := make(chan error, 1)
+	go func() {
+		defer func() {
+			_ = s.Close()
+			close(messages)
+			close(errs)
+		}()
+		for {
+			res, closed, err := s.Next(ctx)
+			if err != nil && !s.sendError(ctx, errs, err, closed) {
+				return
+			}
+			if closed {
+				return
+			}
+			if err != nil {
+				continue
+			}
+			switch s.channelOverflow {
+			case ChannelOverflowDropOldest:
+				for sent := false; !sent; {
+					select {
+					case messages <- res:
+						sent = true
+					default:
+						// the consumer might have taken the oldest message in the meantime
+						select {
+						case <-messages:
+						default:
+						}
+					}
+				}
+			case ChannelOverflowError:
+				select {
+				case messages <- res:
+				default:
+					_ = s.closeWithError(ErrChannelFull)
+					select {
+					case errs <- ErrChannelFull:
+					case <-ctx.Done():
+					}
+					return
+				}
+			default:
+				select {
+				case messages <- res:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return messages, errs
+}
+
+// sendError delivers err on errs, errors which don't end the stream follow the overflow policy like messages.
+// It returns false if the stream ended because of it, or ctx is done.
+func (s *Stream[Response]) sendError(ctx context.Context, errs chan error, err error, closed bool) bool {
+	select {
+	case errs <- err:
+		return true
+	default:
+	}
+	switch {
+	case s.channelOverflow == ChannelOverflowDropOldest:
+		for {
+			select {
+			case errs <- err:
+				return true
+			default:
+				// the consumer might have taken the undelivered error in the meantime
+				select {
+				case <-errs:
+				default:
+				}
+			}
+		}
+	case s.channelOverflow == ChannelOverflowError && !closed:
+		_ = s.closeWithError(ErrChannelFull)
+		select {
+		case errs <- ErrChannelFull:
+		case <-ctx.Done():
+		}
+		return false
+	}
+	// prefer delivering the error, e.g. a timeout of ctx, over giving up because ctx is done
+	select {
+	case errs <- err:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,125 @@
+package execute_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+// subscribeChannel subscribes to a server sending frames and ending the stream, closed is signaled once the stream is closed
+func subscribeChannel(t *testing.T, frames string, opts ...execute.Option) (messages <-chan *[]byte, errs <-chan error, closed <-chan struct{}) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(frames))
+	}))
+	t.Cleanup(srv.Close)
+	done := make(chan struct{})
+	opts = append(opts, execute.WithStreamEvents(func(event execute.StreamEvent) {
+		if _, ok := event.(execute.StreamClosed); ok {
+			close(done)
+		}
+	}))
+	stream, err := execute.New(srv.Client(), srv.URL).Subscribe(context.Background(), "/operations/Counter", nil, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	rawMessages, errs := stream.Channel(ctx)
+	out := make(chan *[]byte, 16)
+	go func() {
+		<-done
+		for res := range rawMessages {
+			data := []byte(*res)
+			out <- &data
+		}
+		close(out)
+	}()
+	return out, errs, done
+}
+
+func collect(messages <-chan *[]byte) []string {
+	var (
+		got []string
+	)
+	for res := range messages {
+		got = append(got, string(*res))
+	}
+	return got
+}
+
+func TestChannelOverflow(t *testing.T) {
+	const frames = `{"data":1}` + "\n\n" + `{"data":2}` + "\n\n" + `{"data":3}` + "\n\n"
+	t.Run("block", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(frames))
+		}))
+		defer srv.Close()
+		stream, err := execute.New(srv.Client(), srv.URL).Subscribe(context.Background(), "/operations/Counter", nil, execute.WithChannelBuffer(1, execute.ChannelOverflowBlock))
+		if err != nil {
+			t.Fatal(err)
+		}
+		messages, errs := stream.Channel(context.Background())
+		var got []string
+		for res := range messages {
+			time.Sleep(10 * time.Millisecond)
+			got = append(got, string(*res))
+		}
+		if len(got) != 3 || got[0] != "1" || got[2] != "3" {
+			t.Fatalf("expected all messages, got %v", got)
+		}
+		if err := <-errs; err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	})
+	t.Run("drop oldest", func(t *testing.T) {
+		messages, errs, _ := subscribeChannel(t, frames, execute.WithChannelBuffer(1, execute.ChannelOverflowDropOldest))
+		if got := collect(messages); len(got) != 1 || got[0] != "3" {
+			t.Fatalf("expected only the latest message, got %v", got)
+		}
+		if err := <-errs; err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		messages, errs, _ := subscribeChannel(t, frames, execute.WithChannelBuffer(1, execute.ChannelOverflowError))
+		if got := collect(messages); len(got) != 1 || got[0] != "1" {
+			t.Fatalf("expected the first message, got %v", got)
+		}
+		if err := <-errs; !errors.Is(err, execute.ErrChannelFull) {
+			t.Fatalf("expected ErrChannelFull, got %v", err)
+		}
+	})
+	t.Run("drop oldest errors", func(t *testing.T) {
+		const frames = `{"errors":[{"message":"a"}]}` + "\n\n" + `{"errors":[{"message":"b"}]}` + "\n\n" + `{"data":1}` + "\n\n"
+		messages, errs, _ := subscribeChannel(t, frames, execute.WithChannelBuffer(1, execute.ChannelOverflowDropOldest))
+		if got := collect(messages); len(got) != 1 || got[0] != "1" {
+			t.Fatalf("expected the message following the errors, got %v", got)
+		}
+		var gqlErr *execute.GraphQLError
+		if err := <-errs; !errors.As(err, &gqlErr) || gqlErr.Errors[0].Message != "b" {
+			t.Fatalf("expected the latest error, got %v", err)
+		}
+	})
+	t.Run("error on errors", func(t *testing.T) {
+		const frames = `{"errors":[{"message":"a"}]}` + "\n\n" + `{"errors":[{"message":"b"}]}` + "\n\n" + `{"data":1}` + "\n\n"
+		messages, errs, closed := subscribeChannel(t, frames, execute.WithChannelBuffer(1, execute.ChannelOverflowError))
+		<-closed
+		var gqlErr *execute.GraphQLError
+		if err := <-errs; !errors.As(err, &gqlErr) {
+			t.Fatalf("expected the first error, got %v", err)
+		}
+		if err := <-errs; !errors.Is(err, execute.ErrChannelFull) {
+			t.Fatalf("expected ErrChannelFull, got %v", err)
+		}
+		if got := collect(messages); len(got) != 0 {
+			t.Fatalf("expected no messages, got %v", got)
+		}
+	})
+}
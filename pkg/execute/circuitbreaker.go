@@ -0,0 +1,132 @@
+package execute
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithCircuitBreaker stops sending requests to a path after threshold consecutive failures.
+// Calls fail with ErrCircuitOpen for cooldown, afterwards a single trial request is sent,
+// which closes the circuit if it succeeds and opens it again otherwise.
+// Failures are transport errors and 5xx responses, canceled calls don't count.
+// State changes are reported to Observers implementing CircuitObserver. This option only has an effect when passed to New.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(o *options) {
+		o.circuitThreshold = threshold
+		o.circuitCooldown = cooldown
+	}
+}
+
+// CircuitState is the state of the circuit of a path, see WithCircuitBreaker
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitObserver can optionally be implemented by an Observer to record state changes of circuits
+type CircuitObserver interface {
+	ObserveCircuitState(path string, state CircuitState)
+}
+
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	mu        sync.Mutex
+	circuits  map[string]*circuit
+}
+
+type circuit struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		circuits:  map[string]*circuit{},
+	}
+}
+
+// allow returns ErrCircuitOpen if requests to path are short-circuited,
+// otherwise done must be called with the outcome of the request
+func (b *circuitBreaker) allow(o *options, path string) (done func(res *http.Response, err error), err error) {
+	b.mu.Lock()
+	c, ok := b.circuits[path]
+	if !ok {
+		c = &circuit{}
+		b.circuits[path] = c
+	}
+	switch c.state {
+	case CircuitOpen:
+		if time.Since(c.openedAt) < b.cooldown {
+			b.mu.Unlock()
+			return nil, ErrCircuitOpen
+		}
+		c.state = CircuitHalfOpen
+		b.mu.Unlock()
+		o.observeCircuitState(path, CircuitHalfOpen)
+	case CircuitHalfOpen:
+		// the trial request is still in flight
+		b.mu.Unlock()
+		return nil, ErrCircuitOpen
+	default:
+		b.mu.Unlock()
+	}
+	return func(res *http.Response, err error) {
+		b.mu.Lock()
+		previous := c.state
+		switch {
+		case errors.Is(err, context.Canceled):
+			// the caller gave up, this says nothing about the backend
+			if c.state == CircuitHalfOpen {
+				c.state = CircuitOpen
+			}
+		case isCircuitFailure(res, err):
+			c.failures++
+			if c.state == CircuitHalfOpen || c.failures >= b.threshold {
+				c.state, c.openedAt = CircuitOpen, time.Now()
+			}
+		default:
+			c.failures, c.state = 0, CircuitClosed
+		}
+		state := c.state
+		b.mu.Unlock()
+		if state != previous {
+			o.observeCircuitState(path, state)
+		}
+	}, nil
+}
+
+// isCircuitFailure reports whether the outcome of a request counts as failure of the backend
+func isCircuitFailure(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return res.StatusCode >= http.StatusInternalServerError
+}
+
+func (o *options) observeCircuitState(path string, state CircuitState) {
+	if observer, ok := o.observer.(CircuitObserver); ok {
+		observer.ObserveCircuitState(path, state)
+	}
+}
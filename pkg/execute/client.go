@@ -0,0 +1,172 @@
+package execute
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Client bundles the http.Client, the base URL of the WunderGraph server and default options.
+// The package level functions like Query and Subscribe use a Client under the hood.
+// A Client is safe for concurrent use by multiple goroutines and should be reused, state shared between calls,
+// like the circuit breaker, WithSingleFlight and NewMemoryCache, is synchronized.
+// Funcs passed to options, e.g. token providers, observers and custom caches, must be safe for concurrent use too.
+// A Stream must only be used by a single goroutine at a time.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	opts       []Option
+	// flight is set by WithSingleFlight
+	flight *singleflight.Group
+	// breaker is set by WithCircuitBreaker
+	breaker *circuitBreaker
+	// endpoints is set by WithEndpoints
+	endpoints *endpointPool
+}
+
+// New creates a Client, opts are applied to every call before the options passed to the call itself.
+// If httpClient is nil, http.DefaultClient is used.
+func New(httpClient *http.Client, baseURL string, opts ...Option) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	o := newOptions(opts)
+	if o.tlsConfig != nil && httpClient.Transport == nil {
+		httpClient = tlsClient(httpClient, o.tlsConfig)
+	}
+	c := &Client{
+		httpClient: httpClient,
+		baseURL:    baseURL,
+		opts:       opts,
+	}
+	if o.singleFlight {
+		c.flight = &singleflight.Group{}
+	}
+	if o.circuitThreshold > 0 {
+		c.breaker = newCircuitBreaker(o.circuitThreshold, o.circuitCooldown)
+	}
+	if len(o.endpoints) > 0 {
+		c.endpoints = newEndpointPool(baseURL, o.endpoints, o.endpointPolicy, o.endpointCooldown)
+	}
+	return c
+}
+
+func (c *Client) options(opts []Option) *options {
+	if len(opts) == 0 {
+		return newOptions(c.opts)
+	}
+	all := make([]Option, 0, len(c.opts)+len(opts))
+	all = append(all, c.opts...)
+	all = append(all, opts...)
+	return newOptions(all)
+}
+
+// operationURL joins the base URL and path and adds params to the query, query parameters of both are kept.
+// The URL set with WithAbsoluteURL replaces both.
+func (c *Client) operationURL(o *options, path string, params url.Values) (string, error) {
+	if o.absoluteURL != "" {
+		u, err := parseAbsoluteURL("URL", o.absoluteURL)
+		if err != nil {
+			return "", err
+		}
+		return addQuery(u, u.Query(), params), nil
+	}
+	base, err := parseAbsoluteURL("base URL", c.baseURL)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: path %q: %w", ErrInvalidURL, path, err)
+	}
+	if ref.Scheme != "" || ref.Host != "" {
+		return "", fmt.Errorf("%w: path %q must be relative to the base URL", ErrInvalidURL, path)
+	}
+	return addQuery(base.JoinPath(ref.Path), base.Query(), ref.Query(), params), nil
+}
+
+// parseAbsoluteURL parses rawURL, which must contain scheme and host, name describes it in errors
+func parseAbsoluteURL(name, rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s %q: %w", ErrInvalidURL, name, rawURL, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("%w: %s %q must contain scheme and host", ErrInvalidURL, name, rawURL)
+	}
+	return u, nil
+}
+
+// addQuery replaces the query of u with all values merged
+func addQuery(u *url.URL, query url.Values, values ...url.Values) string {
+	for _, v := range values {
+		for key, value := range v {
+			query[key] = append(query[key], value...)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// Query executes the query at path and decodes its data into response, which must be a pointer.
+// Like the package level Query, response is populated with partial data if a *GraphQLError is returned.
+func (c *Client) Query(ctx context.Context, path string, input, response any, opts ...Option) error {
+	o := c.options(opts)
+	result, err := query[json.RawMessage](c, ctx, path, input, o)
+	return decodeInto(result, err, response, o)
+}
+
+// Mutate executes the mutation at path and decodes its data into response, which must be a pointer
+func (c *Client) Mutate(ctx context.Context, path string, input, response any, opts ...Option) error {
+	o := c.options(opts)
+	result, err := mutate[json.RawMessage](c, ctx, path, input, o)
+	return decodeInto(result, err, response, o)
+}
+
+// LiveQuery starts the live query at path, messages are returned undecoded
+func (c *Client) LiveQuery(ctx context.Context, path string, input any, opts ...Option) (*Stream[json.RawMessage], error) {
+	return buildStream[json.RawMessage](c, ctx, path, true, input, c.options(opts))
+}
+
+// Subscribe starts the subscription at path, messages are returned undecoded
+func (c *Client) Subscribe(ctx context.Context, path string, input any, opts ...Option) (*Stream[json.RawMessage], error) {
+	return buildStream[json.RawMessage](c, ctx, path, false, input, c.options(opts))
+}
+
+func decodeInto(result *Result[json.RawMessage], err error, response any, o *options) error {
+	if result == nil || result.Data == nil || response == nil {
+		return err
+	}
+	if unmarshal := o.unmarshalerFor(result.Headers); unmarshal != nil {
+		if decodeErr := unmarshal(*result.Data, response); decodeErr != nil {
+			return fmt.Errorf("error decoding response: %w", decodeErr)
+		}
+		return err
+	}
+	if decodeErr := newDecoder(o.codec, bytes.NewReader(*result.Data), o.strictDecoding).Decode(response); decodeErr != nil {
+		if o.strictDecoding {
+			return fmt.Errorf("strict decoding: %w", decodeErr)
+		}
+		return fmt.Errorf("error decoding response: %w", decodeErr)
+	}
+	return err
+}
+
+// hasInput reports whether input is set, a nil pointer counts as no input
+func hasInput(input any) bool {
+	if input == nil {
+		return false
+	}
+	v := reflect.ValueOf(input)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return !v.IsNil()
+	}
+	return true
+}
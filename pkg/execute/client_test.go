@@ -0,0 +1,59 @@
+package execute_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+func TestClientOperationURL(t *testing.T) {
+	var requestURI string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestURI = r.URL.RequestURI()
+		_, _ = w.Write([]byte(`{"data":{}}`))
+	}))
+	defer srv.Close()
+	tests := []struct {
+		name    string
+		baseURL string
+		path    string
+		opts    []execute.Option
+		want    string
+		wantErr error
+	}{
+		{name: "join", baseURL: srv.URL, path: "/operations/Items", want: "/operations/Items"},
+		{name: "double slash", baseURL: srv.URL + "/api/", path: "/operations/Items", want: "/api/operations/Items"},
+		{name: "path without slash", baseURL: srv.URL + "/api", path: "operations/Items", want: "/api/operations/Items"},
+		{name: "query of path", baseURL: srv.URL, path: "/operations/Items?a=1", want: "/operations/Items?a=1"},
+		{name: "query of base URL and path", baseURL: srv.URL + "/?b=2", path: "/operations/Items?a=1", want: "/operations/Items?a=1&b=2"},
+		{name: "absolute URL", baseURL: "http://localhost:9991", path: "/operations/Items", opts: []execute.Option{execute.WithAbsoluteURL(srv.URL + "/custom?a=1")}, want: "/custom?a=1"},
+		{name: "missing scheme", baseURL: "localhost:9991", path: "/operations/Items", wantErr: execute.ErrInvalidURL},
+		{name: "missing host", baseURL: "http://", path: "/operations/Items", wantErr: execute.ErrInvalidURL},
+		{name: "invalid base URL", baseURL: "http://localhost:9991/%zz", path: "/operations/Items", wantErr: execute.ErrInvalidURL},
+		{name: "invalid path", baseURL: srv.URL, path: "/operations/%zz", wantErr: execute.ErrInvalidURL},
+		{name: "absolute path", baseURL: srv.URL, path: "http://example.com/operations/Items", wantErr: execute.ErrInvalidURL},
+		{name: "absolute URL without scheme", baseURL: srv.URL, path: "/operations/Items", opts: []execute.Option{execute.WithAbsoluteURL("example.com/custom")}, wantErr: execute.ErrInvalidURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestURI = ""
+			err := execute.New(srv.Client(), tt.baseURL).Query(context.Background(), tt.path, nil, nil, tt.opts...)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if requestURI != tt.want {
+				t.Fatalf("expected a request to %s, got %s", tt.want, requestURI)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package execute
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Codec encodes inputs and decodes responses, it allows replacing encoding/json with a compatible implementation
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+	NewDecoder(r io.Reader) Decoder
+}
+
+// Decoder is implemented by *json.Decoder and most of its drop-in replacements
+type Decoder interface {
+	Decode(v any) error
+}
+
+// DefaultCodec is used unless a Codec is configured with WithCodec
+var DefaultCodec Codec = JSONCodec{}
+
+// JSONCodec implements Codec using encoding/json
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (JSONCodec) NewDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
+
+// newDecoder returns a decoder which rejects unknown fields if strict is set and the Decoder supports it
+func newDecoder(codec Codec, r io.Reader, strict bool) Decoder {
+	dec := codec.NewDecoder(r)
+	if strict {
+		if d, ok := dec.(interface{ DisallowUnknownFields() }); ok {
+			d.DisallowUnknownFields()
+		}
+	}
+	return dec
+}
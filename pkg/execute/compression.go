@@ -0,0 +1,109 @@
+package execute
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// WithCompression asks the server for a gzip or deflate compressed response and decompresses it transparently.
+// deflate is accepted both zlib wrapped, as specified, and raw, as sent by some servers. Uncompressed responses are handled as usual.
+func WithCompression() Option {
+	return func(o *options) {
+		o.compression = true
+	}
+}
+
+// WithRequestGzip compresses Mutate request bodies with gzip if they are larger than minSize bytes,
+// smaller bodies are sent uncompressed to avoid the overhead.
+func WithRequestGzip(minSize int) Option {
+	return func(o *options) {
+		o.requestGzip = true
+		o.requestGzipMinSize = minSize
+	}
+}
+
+func (o *options) shouldCompressRequest(body []byte) bool {
+	return o.requestGzip && len(body) > o.requestGzipMinSize
+}
+
+func gzipBody(body []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	if _, err := gz.Write(body); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressResponse replaces the body of a compressed response with a decompressing reader
+func decompressResponse(res *http.Response) {
+	var (
+		newReader func(r io.Reader) (io.ReadCloser, error)
+	)
+	switch strings.ToLower(strings.TrimSpace(res.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		newReader = func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		}
+	case "deflate":
+		newReader = newDeflateReader
+	default:
+		return
+	}
+	res.Body = &decompressingBody{
+		body:      res.Body,
+		newReader: newReader,
+	}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = true
+}
+
+// newDeflateReader decompresses zlib wrapped and raw DEFLATE data, which is told apart by the zlib header
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil && len(header) == 0 {
+		return nil, err
+	}
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// decompressingBody creates the decompressor lazily on the first Read,
+// because reading the compression header would otherwise block until a stream sends its first message
+type decompressingBody struct {
+	body      io.ReadCloser
+	newReader func(r io.Reader) (io.ReadCloser, error)
+	reader    io.ReadCloser
+	err       error
+}
+
+func (d *decompressingBody) Read(p []byte) (int, error) {
+	if d.reader == nil && d.err == nil {
+		d.reader, d.err = d.newReader(d.body)
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	return d.reader.Read(p)
+}
+
+func (d *decompressingBody) Close() error {
+	if d.reader != nil {
+		_ = d.reader.Close()
+	}
+	return d.body.Close()
+}
@@ -0,0 +1,56 @@
+package execute_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+func TestCompression(t *testing.T) {
+	const body = `{"data":{"id":1}}`
+	compress := func(newWriter func(w io.Writer) io.WriteCloser) []byte {
+		var buf bytes.Buffer
+		w := newWriter(&buf)
+		_, _ = w.Write([]byte(body))
+		_ = w.Close()
+		return buf.Bytes()
+	}
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+	}{
+		{name: "gzip", encoding: "gzip", body: compress(func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })},
+		{name: "zlib deflate", encoding: "deflate", body: compress(func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) })},
+		{name: "raw deflate", encoding: "deflate", body: compress(func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		})},
+		{name: "uncompressed", body: []byte(body)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.encoding != "" {
+					w.Header().Set("Content-Encoding", tt.encoding)
+				}
+				_, _ = w.Write(tt.body)
+			}))
+			defer srv.Close()
+			var response struct {
+				ID int `json:"id"`
+			}
+			if err := execute.New(srv.Client(), srv.URL, execute.WithCompression()).Query(context.Background(), "/operations/Item", nil, &response); err != nil || response.ID != 1 {
+				t.Fatalf("expected the response to be decompressed, got %v, err %v", response, err)
+			}
+		})
+	}
+}
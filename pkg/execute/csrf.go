@@ -0,0 +1,77 @@
+package execute
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// defaultCSRFHeader is the header WunderGraph expects the CSRF token in
+const defaultCSRFHeader = "X-CSRF-Token"
+
+// WithCSRF sends the token returned by tokenProvider in headerName with every Mutate and MutateUpload request,
+// headerName defaults to X-CSRF-Token. tokenProvider is called per request, see NewCSRFTokenProvider for one which caches the token.
+func WithCSRF(tokenProvider func(ctx context.Context) (string, error), headerName string) Option {
+	if headerName == "" {
+		headerName = defaultCSRFHeader
+	}
+	return func(o *options) {
+		o.csrfToken = tokenProvider
+		o.csrfHeader = headerName
+	}
+}
+
+// NewCSRFTokenProvider returns a token provider for WithCSRF, which fetches the token from the endpoint at url once,
+// e.g. https://api.example.com/auth/cookie/csrf. The endpoint returns the token as plain text body.
+// client must share its cookie jar with the Client, because the token is bound to the session cookie.
+// Failed fetches are retried by the next call.
+func NewCSRFTokenProvider(client *http.Client, url string) func(ctx context.Context) (string, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var (
+		mu    sync.Mutex
+		token string
+	)
+	return func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if token != "" {
+			return token, nil
+		}
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return "", err
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		if !isSuccess(res.StatusCode) {
+			return "", newAPIError(res, nil)
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+		if err != nil {
+			return "", err
+		}
+		token = strings.TrimSpace(string(body))
+		return token, nil
+	}
+}
+
+// setCSRFToken adds the token configured with WithCSRF to req
+func (o *options) setCSRFToken(req *http.Request) error {
+	if o.csrfToken == nil {
+		return nil
+	}
+	token, err := o.csrfToken(req.Context())
+	if err != nil {
+		return fmt.Errorf("error fetching csrf token: %w", err)
+	}
+	req.Header.Set(o.csrfHeader, token)
+	return nil
+}
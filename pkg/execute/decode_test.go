@@ -0,0 +1,121 @@
+package execute_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+type item struct {
+	ID int `json:"id"`
+}
+
+func TestMutateResponseBody(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		want       *item
+		wantErr    string
+	}{
+		{name: "no content", statusCode: http.StatusNoContent},
+		{name: "empty 200", statusCode: http.StatusOK},
+		{name: "empty 202", statusCode: http.StatusAccepted},
+		{name: "data", statusCode: http.StatusOK, body: `{"data":{"id":1}}`, want: &item{ID: 1}},
+		{name: "truncated", statusCode: http.StatusOK, body: `{"data":{"id":`, wantErr: "error decoding response"},
+		{name: "not JSON", statusCode: http.StatusOK, body: `<html></html>`, wantErr: "error decoding response"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			res, err := execute.Mutate[struct{}, item](srv.Client(), context.Background(), srv.URL, "/operations/CreateItem", nil)
+			if tt.wantErr == "" {
+				if err != nil || (res == nil) != (tt.want == nil) || (res != nil && *res != *tt.want) {
+					t.Fatalf("expected %v without error, got %v, %v", tt.want, res, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// queryBody queries a server responding with body and decodes the data into Response
+func queryBody[Response any](t *testing.T, body string) (*Response, error) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	return execute.Query[struct{}, Response](srv.Client(), context.Background(), srv.URL, "/operations/Item", nil)
+}
+
+func TestQueryUntypedResponse(t *testing.T) {
+	const (
+		data    = `{"data":{"id":1}}`
+		partial = `{"data":{"id":1},"errors":[{"message":"not found"}]}`
+		null    = `{"data":null,"errors":[{"message":"not found"}]}`
+	)
+	expectGraphQLError := func(t *testing.T, err error, wantErr bool) {
+		t.Helper()
+		var graphQLErr *execute.GraphQLError
+		if wantErr != errors.As(err, &graphQLErr) {
+			t.Fatalf("expected a *GraphQLError: %v, got %v", wantErr, err)
+		}
+		if wantErr && (len(graphQLErr.Errors) != 1 || graphQLErr.Errors[0].Message != "not found") {
+			t.Fatalf("unexpected errors %v", graphQLErr.Errors)
+		}
+	}
+	for _, tt := range []struct{ name, body string }{{"data", data}, {"partial data", partial}} {
+		body, wantErr := tt.body, tt.body == partial
+		t.Run("typed "+tt.name, func(t *testing.T) {
+			res, err := queryBody[item](t, body)
+			expectGraphQLError(t, err, wantErr)
+			if res == nil || res.ID != 1 {
+				t.Fatalf("unexpected response %v", res)
+			}
+		})
+		t.Run("json.RawMessage "+tt.name, func(t *testing.T) {
+			res, err := queryBody[json.RawMessage](t, body)
+			expectGraphQLError(t, err, wantErr)
+			if res == nil || string(*res) != `{"id":1}` {
+				t.Fatalf("unexpected response %s", deref(res))
+			}
+		})
+		t.Run("map "+tt.name, func(t *testing.T) {
+			res, err := queryBody[map[string]any](t, body)
+			expectGraphQLError(t, err, wantErr)
+			if res == nil || !reflect.DeepEqual(*res, map[string]any{"id": float64(1)}) {
+				t.Fatalf("unexpected response %v", res)
+			}
+		})
+	}
+	t.Run("json.RawMessage null data", func(t *testing.T) {
+		res, err := queryBody[json.RawMessage](t, null)
+		expectGraphQLError(t, err, true)
+		if res != nil {
+			t.Fatalf("expected no data, got %s", deref(res))
+		}
+	})
+	t.Run("map null data", func(t *testing.T) {
+		res, err := queryBody[map[string]any](t, null)
+		expectGraphQLError(t, err, true)
+		if res != nil {
+			t.Fatalf("expected no data, got %v", res)
+		}
+	})
+}
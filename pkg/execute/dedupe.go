@@ -0,0 +1,77 @@
+package execute
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// WithDedupe makes Stream.Next skip LiveQuery and Subscribe messages which are equal to the previous one,
+// e.g. unchanged snapshots re-sent by the server. If equal is nil, messages are compared byte by byte,
+// otherwise equal is called with the undecoded data of both messages, e.g. to ignore a timestamp field.
+// Messages carrying errors are always returned. To compare decoded responses, use WithDedupeFunc.
+func WithDedupe(equal func(a, b []byte) bool) Option {
+	return func(o *options) {
+		o.dedupe = true
+		o.dedupeEqual, o.dedupeFunc = equal, nil
+	}
+}
+
+// WithDedupeFunc is like WithDedupe, but equal compares the decoded data of both messages.
+// Response must be the Response of the stream, e.g. json.RawMessage for Client.LiveQuery and Client.Subscribe,
+// otherwise starting the stream fails instead of never skipping a message.
+func WithDedupeFunc[Response any](equal func(a, b *Response) bool) Option {
+	return func(o *options) {
+		o.dedupe = true
+		o.dedupeEqual, o.dedupeFunc = nil, equal
+	}
+}
+
+// resolveDedupeFunc replaces the func passed to WithDedupeFunc with one comparing undecoded data,
+// it fails if the func doesn't compare the Response of the stream
+func resolveDedupeFunc[Response any](o *options) error {
+	if o.dedupeFunc == nil {
+		return nil
+	}
+	equal, ok := o.dedupeFunc.(func(a, b *Response) bool)
+	if !ok {
+		return fmt.Errorf("WithDedupeFunc: %T doesn't compare %T, the Response of the stream", o.dedupeFunc, new(Response))
+	}
+	codec := o.codec
+	o.dedupeEqual = func(a, b []byte) bool {
+		var x, y Response
+		if codec.Unmarshal(a, &x) != nil || codec.Unmarshal(b, &y) != nil {
+			return false
+		}
+		return equal(&x, &y)
+	}
+	o.dedupeFunc = nil
+	return nil
+}
+
+// newDedupe returns a func reporting whether a frame repeats the previous one
+func newDedupe(equal func(a, b []byte) bool, codec Codec) func(frame []byte) bool {
+	if equal != nil {
+		var (
+			last json.RawMessage
+		)
+		return func(frame []byte) bool {
+			var envelope responseEnvelope[json.RawMessage]
+			if err := codec.Unmarshal(frame, &envelope); err != nil || envelope.Data == nil {
+				return false
+			}
+			duplicate := last != nil && equal(last, *envelope.Data)
+			last = append(last[:0], *envelope.Data...)
+			return duplicate
+		}
+	}
+	var (
+		last  []byte
+		first = true
+	)
+	return func(frame []byte) bool {
+		duplicate := !first && bytes.Equal(last, frame)
+		last, first = append(last[:0], frame...), false
+		return duplicate
+	}
+}
@@ -0,0 +1,99 @@
+package execute_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+const dedupeFrames = `{"data":{"n":1,"at":1}}` + "\n\n" + `{"data":{"n":1,"at":1}}` + "\n\n" + `{"data":{"n":1,"at":2}}` + "\n\n" + `{"data":{"n":2,"at":3}}` + "\n\n"
+
+func TestDedupe(t *testing.T) {
+	frames := dedupeFrames
+	ignoreAt := func(a, b []byte) bool {
+		var x, y struct{ N int }
+		return json.Unmarshal(a, &x) == nil && json.Unmarshal(b, &y) == nil && x == y
+	}
+	tests := []struct {
+		name  string
+		equal func(a, b []byte) bool
+		want  []string
+	}{
+		{name: "bytes", want: []string{`{"n":1,"at":1}`, `{"n":1,"at":2}`, `{"n":2,"at":3}`}},
+		{name: "equal", equal: ignoreAt, want: []string{`{"n":1,"at":1}`, `{"n":2,"at":3}`}},
+		{name: "equal bytes", equal: bytes.Equal, want: []string{`{"n":1,"at":1}`, `{"n":1,"at":2}`, `{"n":2,"at":3}`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newStreamServer(t, frames)
+			stream, err := execute.New(srv.Client(), srv.URL).LiveQuery(context.Background(), "/operations/Counter", nil, execute.WithDedupe(tt.equal))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer stream.Close()
+			for _, want := range tt.want {
+				res, closed, err := stream.Next(context.Background())
+				if err != nil || closed {
+					t.Fatalf("unexpected closed %v, err %v", closed, err)
+				}
+				if string(*res) != want {
+					t.Fatalf("expected %s, got %s", want, *res)
+				}
+			}
+		})
+	}
+}
+
+func TestDedupeFunc(t *testing.T) {
+	type counter struct {
+		N  int `json:"n"`
+		At int `json:"at"`
+	}
+	srv := newStreamServer(t, dedupeFrames)
+	ignoreAt := execute.WithDedupeFunc(func(a, b *counter) bool {
+		return a.N == b.N
+	})
+	stream, err := execute.LiveQuery[struct{}, counter](srv.Client(), context.Background(), srv.URL, "/operations/Counter", nil, ignoreAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	for _, want := range []counter{{N: 1, At: 1}, {N: 2, At: 3}} {
+		res, closed, err := stream.Next(context.Background())
+		if err != nil || closed || *res != want {
+			t.Fatalf("expected %v, got %v, closed %v, err %v", want, res, closed, err)
+		}
+	}
+}
+
+func TestDedupeFuncRaw(t *testing.T) {
+	srv := newStreamServer(t, dedupeFrames)
+	c := execute.New(srv.Client(), srv.URL)
+	stream, err := c.LiveQuery(context.Background(), "/operations/Counter", nil, execute.WithDedupeFunc(func(a, b *json.RawMessage) bool {
+		return bytes.Equal(*a, *b)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	for _, want := range []string{`{"n":1,"at":1}`, `{"n":1,"at":2}`} {
+		res, closed, err := stream.Next(context.Background())
+		if err != nil || closed || string(*res) != want {
+			t.Fatalf("expected %s, got %s, closed %v, err %v", want, deref(res), closed, err)
+		}
+	}
+}
+
+func TestDedupeFuncTypeMismatch(t *testing.T) {
+	srv := newStreamServer(t, dedupeFrames)
+	_, err := execute.New(srv.Client(), srv.URL).LiveQuery(context.Background(), "/operations/Counter", nil, execute.WithDedupeFunc(func(a, b *struct{ N int }) bool {
+		return a.N == b.N
+	}))
+	if err == nil || !strings.Contains(err.Error(), "WithDedupeFunc") {
+		t.Fatalf("expected starting the stream to fail, got %v", err)
+	}
+}
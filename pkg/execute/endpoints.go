@@ -0,0 +1,184 @@
+package execute
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EndpointPolicy decides which endpoint set with WithEndpoints receives a request
+type EndpointPolicy int
+
+const (
+	// EndpointFailover sends requests to the first endpoint and only uses the next ones while it's unreachable
+	EndpointFailover EndpointPolicy = iota
+	// EndpointRoundRobin distributes requests across all endpoints
+	EndpointRoundRobin
+)
+
+// WithEndpoints sends requests to the base URL of the Client to one of endpoints instead, which are base URLs too.
+// If an endpoint is unreachable, the request is sent to the next one. Only connection errors, e.g. refused connections
+// and DNS errors, fail over, responses like 4xx and 5xx are returned as they are.
+// Requests with a body which can't be replayed, e.g. MutateUpload, don't fail over.
+// This option only has an effect when passed to New.
+func WithEndpoints(endpoints []string, policy EndpointPolicy) Option {
+	endpoints = append([]string(nil), endpoints...)
+	return func(o *options) {
+		o.endpoints = endpoints
+		o.endpointPolicy = policy
+	}
+}
+
+// WithEndpointCooldown skips endpoints which were unreachable within cooldown, see WithEndpoints.
+// If all endpoints failed recently, they are tried anyway. This option only has an effect when passed to New.
+func WithEndpointCooldown(cooldown time.Duration) Option {
+	return func(o *options) {
+		o.endpointCooldown = cooldown
+	}
+}
+
+type endpointPool struct {
+	base      *url.URL
+	endpoints []*url.URL
+	policy    EndpointPolicy
+	cooldown  time.Duration
+	// err is returned by all requests if the URLs are invalid
+	err  error
+	next atomic.Uint64
+	mu   sync.Mutex
+	// failedAt is the time of the last connection error per endpoint
+	failedAt []time.Time
+}
+
+func newEndpointPool(baseURL string, endpoints []string, policy EndpointPolicy, cooldown time.Duration) *endpointPool {
+	p := &endpointPool{
+		policy:   policy,
+		cooldown: cooldown,
+		failedAt: make([]time.Time, len(endpoints)),
+	}
+	p.base, p.err = parseAbsoluteURL("base URL", baseURL)
+	for _, endpoint := range endpoints {
+		u, err := parseAbsoluteURL("endpoint", endpoint)
+		if err != nil {
+			p.err = err
+		}
+		p.endpoints = append(p.endpoints, u)
+	}
+	return p
+}
+
+// order returns the indexes of the endpoints in the order they should be tried
+func (p *endpointPool) order() []int {
+	start := 0
+	if p.policy == EndpointRoundRobin {
+		start = int((p.next.Add(1) - 1) % uint64(len(p.endpoints)))
+	}
+	var (
+		healthy, failed []int
+	)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.endpoints {
+		endpoint := (start + i) % len(p.endpoints)
+		if p.cooldown > 0 && time.Since(p.failedAt[endpoint]) < p.cooldown {
+			failed = append(failed, endpoint)
+		} else {
+			healthy = append(healthy, endpoint)
+		}
+	}
+	return append(healthy, failed...)
+}
+
+func (p *endpointPool) setFailed(endpoint int, failed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if failed {
+		p.failedAt[endpoint] = time.Now()
+	} else {
+		p.failedAt[endpoint] = time.Time{}
+	}
+}
+
+// owns reports whether u belongs to the base URL, its path must be the path of the base URL or below it
+func (p *endpointPool) owns(u *url.URL) bool {
+	if u.Scheme != p.base.Scheme || u.Host != p.base.Host {
+		return false
+	}
+	base := strings.TrimSuffix(p.base.Path, "/")
+	return u.Path == base || strings.HasPrefix(u.Path, base+"/")
+}
+
+// rewrite moves u from the base URL to endpoint
+func (p *endpointPool) rewrite(u *url.URL, endpoint *url.URL) *url.URL {
+	return &url.URL{
+		Scheme:   endpoint.Scheme,
+		User:     endpoint.User,
+		Host:     endpoint.Host,
+		Path:     strings.TrimSuffix(endpoint.Path, "/") + strings.TrimPrefix(u.Path, strings.TrimSuffix(p.base.Path, "/")),
+		RawQuery: u.RawQuery,
+	}
+}
+
+// endpointTransport sends requests to the endpoints of the pool and fails over on connection errors
+type endpointTransport struct {
+	pool *endpointPool
+	next http.RoundTripper
+}
+
+func (t *endpointTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p := t.pool
+	if p.err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, p.err
+	}
+	if !p.owns(req.URL) {
+		return t.next.RoundTrip(req)
+	}
+	var (
+		lastErr error
+	)
+	for i, endpoint := range p.order() {
+		r := req.Clone(req.Context())
+		r.URL = p.rewrite(req.URL, p.endpoints[endpoint])
+		r.Host = ""
+		if i > 0 && req.Body != nil && req.Body != http.NoBody {
+			// the body was consumed by the previous attempt
+			if req.GetBody == nil {
+				return nil, lastErr
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r.Body = body
+		}
+		res, err := t.next.RoundTrip(r)
+		if err == nil {
+			p.setFailed(endpoint, false)
+			return res, nil
+		}
+		if req.Context().Err() != nil || !isConnectionError(err) {
+			return nil, err
+		}
+		p.setFailed(endpoint, true)
+		lastErr = err
+	}
+	return nil, fmt.Errorf("all endpoints failed: %w", lastErr)
+}
+
+// isConnectionError reports whether err occurred before the request reached the server
+func isConnectionError(err error) bool {
+	var (
+		dnsErr *net.DNSError
+		opErr  *net.OpError
+	)
+	return errors.As(err, &dnsErr) || errors.As(err, &opErr) && opErr.Op == "dial"
+}
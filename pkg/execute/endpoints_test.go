@@ -0,0 +1,90 @@
+package execute_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+// newEndpoint serves statusCode and records the paths of the requests it receives
+func newEndpoint(t *testing.T, statusCode int) (*httptest.Server, *[]string) {
+	t.Helper()
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.WriteHeader(statusCode)
+		_, _ = w.Write([]byte(`{"data":{}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &paths
+}
+
+// unreachableURL returns the URL of a server which refuses connections
+func unreachableURL() string {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	return srv.URL
+}
+
+func TestEndpointsFailover(t *testing.T) {
+	srv, paths := newEndpoint(t, http.StatusOK)
+	c := execute.New(nil, "http://gateway.invalid/api", execute.WithEndpoints([]string{unreachableURL(), srv.URL + "/v1"}, execute.EndpointFailover))
+	if err := c.Query(context.Background(), "/operations/Items", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(*paths) != 1 || (*paths)[0] != "/v1/operations/Items" {
+		t.Fatalf("expected the request to fail over to the second endpoint, got %v", *paths)
+	}
+}
+
+func TestEndpointsNoFailoverOnStatus(t *testing.T) {
+	unavailable, unavailablePaths := newEndpoint(t, http.StatusServiceUnavailable)
+	srv, paths := newEndpoint(t, http.StatusOK)
+	c := execute.New(nil, "http://gateway.invalid", execute.WithEndpoints([]string{unavailable.URL, srv.URL}, execute.EndpointFailover))
+	err := c.Query(context.Background(), "/operations/Items", nil, nil)
+	var apiErr *execute.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected the 503 of the first endpoint, got %v", err)
+	}
+	if len(*unavailablePaths) != 1 || len(*paths) != 0 {
+		t.Fatalf("expected no failover, got %v and %v", *unavailablePaths, *paths)
+	}
+}
+
+func TestEndpointsOnlyRewriteOwnedURLs(t *testing.T) {
+	var foreign atomic.Int64
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		foreign.Add(1)
+		_, _ = w.Write([]byte(`{"data":{}}`))
+	}))
+	defer other.Close()
+	srv, paths := newEndpoint(t, http.StatusOK)
+	c := execute.New(nil, other.URL+"/api", execute.WithEndpoints([]string{srv.URL}, execute.EndpointFailover))
+	tests := []struct {
+		url         string
+		wantForeign bool
+	}{
+		{url: other.URL + "/api/operations/Items"},
+		{url: other.URL + "/apix/operations/Items", wantForeign: true},
+		{url: other.URL + "/operations/Items", wantForeign: true},
+	}
+	for _, tt := range tests {
+		foreign.Store(0)
+		*paths = nil
+		if err := c.Query(context.Background(), "/operations/Items", nil, nil, execute.WithAbsoluteURL(tt.url)); err != nil {
+			t.Fatal(err)
+		}
+		if tt.wantForeign != (foreign.Load() == 1) || tt.wantForeign != (len(*paths) == 0) {
+			t.Errorf("%s: expected the request to reach the foreign server: %v, got requests to the endpoint %v", tt.url, tt.wantForeign, *paths)
+		}
+	}
+	*paths = nil
+	if err := c.Query(context.Background(), "/operations/Items", nil, nil); err != nil || len(*paths) != 1 || (*paths)[0] != "/operations/Items" {
+		t.Fatalf("expected the request to be rewritten to the endpoint, got %v, err %v", *paths, err)
+	}
+}
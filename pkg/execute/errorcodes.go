@@ -0,0 +1,49 @@
+package execute
+
+import (
+	"maps"
+)
+
+// WithErrorCodeMapper maps the extensions.code of GraphQL errors to errors, e.g. {"UNAUTHENTICATED": ErrUnauthenticated},
+// so that errors.Is(err, ErrUnauthenticated) and errors.As match them. The *GraphQLError, or the *APIError for responses
+// with a status other than 2xx, is still returned and wraps the errors of all entries with a mapped code. Register "" to match entries without a code.
+func WithErrorCodeMapper(codes map[string]error) Option {
+	codes = maps.Clone(codes)
+	return func(o *options) {
+		o.errorCodes = codes
+	}
+}
+
+// Code returns the extensions.code of the entry, e.g. GRAPHQL_VALIDATION_FAILED, it's empty if the server didn't set one
+func (e GraphQLErrorEntry) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+// newGraphQLError returns a *GraphQLError wrapping the errors codes maps the codes of entries to
+func newGraphQLError(entries []GraphQLErrorEntry, codes map[string]error) *GraphQLError {
+	return &GraphQLError{Errors: entries, mapped: mapErrorCodes(entries, codes)}
+}
+
+// mapErrorCodes returns the errors codes maps the codes of entries to
+func mapErrorCodes(entries []GraphQLErrorEntry, codes map[string]error) []error {
+	var (
+		mapped []error
+	)
+	for _, entry := range entries {
+		if err, ok := codes[entry.Code()]; ok && err != nil {
+			mapped = append(mapped, err)
+		}
+	}
+	return mapped
+}
+
+// Unwrap returns the errors the codes of the entries are mapped to, see WithErrorCodeMapper
+func (e *GraphQLError) Unwrap() []error {
+	return e.mapped
+}
+
+// Unwrap returns the errors the codes of Errors are mapped to, see WithErrorCodeMapper
+func (e *APIError) Unwrap() []error {
+	return e.mapped
+}
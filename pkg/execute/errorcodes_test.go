@@ -0,0 +1,48 @@
+package execute_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+var errUnauthenticated = errors.New("unauthenticated")
+
+func TestErrorCodeMapper(t *testing.T) {
+	const body = `{"errors":[{"message":"not logged in","extensions":{"code":"UNAUTHENTICATED"}}]}`
+	tests := []struct {
+		name       string
+		statusCode int
+		target     any
+	}{
+		{name: "graphql error", statusCode: http.StatusOK, target: new(*execute.GraphQLError)},
+		{name: "api error", statusCode: http.StatusUnauthorized, target: new(*execute.APIError)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(body))
+			}))
+			defer srv.Close()
+			var response map[string]any
+			c := execute.New(srv.Client(), srv.URL, execute.WithErrorCodeMapper(map[string]error{"UNAUTHENTICATED": errUnauthenticated}))
+			err := c.Query(context.Background(), "/operations/Me", nil, &response)
+			if !errors.Is(err, errUnauthenticated) {
+				t.Fatalf("expected the mapped error, got %v", err)
+			}
+			if !errors.As(err, tt.target) {
+				t.Fatalf("expected %T, got %T", tt.target, err)
+			}
+			err = execute.New(srv.Client(), srv.URL).Query(context.Background(), "/operations/Me", nil, &response)
+			if errors.Is(err, errUnauthenticated) {
+				t.Fatal("expected no mapped error without WithErrorCodeMapper")
+			}
+		})
+	}
+}
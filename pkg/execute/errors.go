@@ -0,0 +1,216 @@
+package execute
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrBadRequest, ErrUnauthorized, ErrInternalServer and ErrUnknown match an *APIError with the respective status,
+	// e.g. errors.Is(err, ErrUnauthorized)
+	ErrBadRequest     = errors.New("bad request")
+	ErrUnauthorized   = errors.New("unauthorized")
+	ErrInternalServer = errors.New("internal server error")
+	ErrUnknown        = errors.New("unknown error")
+	// ErrRateLimited matches 429 responses, APIError.RetryAfter tells when to try again
+	ErrRateLimited = errors.New("rate limited")
+	// ErrNotModified is returned for 304 Not Modified responses to conditional requests,
+	// the ETag can be read from the headers of the Result returned by QueryWithResponse
+	ErrNotModified = errors.New("not modified")
+	// ErrEncodingInput is returned if the input can't be encoded
+	ErrEncodingInput = errors.New("error encoding input")
+	// ErrInvalidInput is returned if the input was rejected by the validator set with WithInputValidator
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrInvalidURL is returned if the base URL and path don't form a valid URL
+	ErrInvalidURL = errors.New("invalid url")
+	// ErrCircuitOpen is returned without sending a request while the circuit of a path is open, see WithCircuitBreaker
+	ErrCircuitOpen = errors.New("circuit open")
+	// ErrStreamClosed is returned by Stream.Next if the stream was already closed
+	ErrStreamClosed = errors.New("stream is closed")
+	// ErrUnexpectedEndOfStream is returned by Stream.Next if the connection ended in the middle of a message
+	ErrUnexpectedEndOfStream = errors.New("unexpected end of stream")
+	// ErrConnectionRefused, ErrDNS, ErrTLS and ErrTimeout classify errors which occur before a response is received,
+	// the underlying error is wrapped as well
+	ErrConnectionRefused = errors.New("connection refused")
+	ErrDNS               = errors.New("dns lookup failed")
+	ErrTLS               = errors.New("tls handshake failed")
+	ErrTimeout           = errors.New("timeout")
+	// ErrReconnected is returned by Stream.Next after the stream was re-established, messages might have been missed
+	ErrReconnected = errors.New("stream reconnected")
+	// ErrFrameTooLarge is returned by Stream.Next if a message exceeds the limit set with WithMaxFrameSize
+	ErrFrameTooLarge = errors.New("stream frame too large")
+	// ErrPostStreamNotSupported is returned by LiveQuery and Subscribe with WithPostQuery if the server only accepts GET requests for streams
+	ErrPostStreamNotSupported = errors.New("server doesn't support streams sent as POST request")
+	// ErrChannelFull is delivered by Stream.Channel with ChannelOverflowError if the consumer doesn't keep up
+	ErrChannelFull = errors.New("stream channel full")
+	// ErrResponseTooLarge is returned by Query and Mutate if the response exceeds the limit set with WithMaxResponseBytes
+	ErrResponseTooLarge = errors.New("response too large")
+	// ErrUnhealthy is returned by Client.Ping if the health check responds with a status other than 2xx, the *APIError is wrapped as well
+	ErrUnhealthy = errors.New("server unhealthy")
+)
+
+const (
+	// maxErrorBodySize limits how much of an error response body is kept in an APIError
+	maxErrorBodySize = 64 << 10
+	// maxErrorBodyDisplay limits how much of the body is rendered by APIError.Error
+	maxErrorBodyDisplay = 256
+)
+
+// APIError is returned when the server responds with a status code outside of the 2xx range.
+// Use errors.As to inspect the status code and the response body.
+type APIError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+	// ContentType is the Content-Type header of the response
+	ContentType string
+	// Errors is set if the body contains a GraphQL errors array
+	Errors []GraphQLErrorEntry
+	// RetryAfter is parsed from the Retry-After header, it's 0 if the header is missing
+	RetryAfter time.Duration
+	// mapped holds the errors of WithErrorCodeMapper matching the codes of Errors
+	mapped []error
+}
+
+func (e *APIError) Error() string {
+	status := fmt.Sprintf("unexpected status %d", e.StatusCode)
+	if e.ContentType != "" {
+		status += " (" + e.ContentType + ")"
+	}
+	if len(e.Body) == 0 {
+		return status
+	}
+	body, truncated := e.Body, false
+	if len(body) > maxErrorBodyDisplay {
+		body, truncated = body[:maxErrorBodyDisplay], true
+	}
+	// collapse the line breaks and indentation of HTML pages into a single readable line
+	snippet := strings.Join(strings.Fields(string(body)), " ")
+	if truncated {
+		snippet += "..."
+	}
+	return status + ": " + snippet
+}
+
+// Is keeps the sentinel errors usable with errors.Is, e.g. errors.Is(err, ErrUnauthorized)
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrInternalServer:
+		return e.StatusCode == http.StatusInternalServerError
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUnknown:
+		return e.StatusCode != http.StatusBadRequest &&
+			e.StatusCode != http.StatusUnauthorized &&
+			e.StatusCode != http.StatusInternalServerError &&
+			e.StatusCode != http.StatusTooManyRequests
+	}
+	return false
+}
+
+// newAPIError consumes and closes the response body, codes are set by WithErrorCodeMapper
+func newAPIError(res *http.Response, codes map[string]error) *APIError {
+	defer res.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+	apiErr := &APIError{
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Body:       body,
+		// e.g. text/html for the error page of a proxy
+		ContentType: res.Header.Get("Content-Type"),
+	}
+	if d, ok := retryAfter(res.Header); ok {
+		apiErr.RetryAfter = d
+	}
+	var envelope struct {
+		Errors []GraphQLErrorEntry `json:"errors"`
+	}
+	if json.Unmarshal(body, &envelope) == nil {
+		apiErr.Errors = envelope.Errors
+		apiErr.mapped = mapErrorCodes(envelope.Errors, codes)
+	}
+	return apiErr
+}
+
+func isSuccess(statusCode int) bool {
+	return statusCode >= 200 && statusCode < 300
+}
+
+// GraphQLError is returned when the response contains a non-empty errors array.
+// Query, Mutate and their WithResponse variants still return the partial data alongside it.
+type GraphQLError struct {
+	Errors []GraphQLErrorEntry
+	// mapped holds the errors of WithErrorCodeMapper matching the codes of the entries
+	mapped []error
+}
+
+// GraphQLErrorEntry is a single entry of the errors array of a response
+type GraphQLErrorEntry struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+func (e *GraphQLError) Error() string {
+	switch len(e.Errors) {
+	case 0:
+		return "graphql error"
+	case 1:
+		return "graphql error: " + e.Errors[0].Message
+	default:
+		return fmt.Sprintf("graphql error: %s (and %d more)", e.Errors[0].Message, len(e.Errors)-1)
+	}
+}
+
+// requestError classifies errors returned by http.Client.Do
+func requestError(req *http.Request, err error) error {
+	target := req.URL.Scheme + "://" + req.URL.Host
+	var (
+		dnsErr *net.DNSError
+		netErr net.Error
+	)
+	switch {
+	case errors.Is(err, context.Canceled):
+		return err
+	case errors.As(err, &dnsErr):
+		return fmt.Errorf("%w: %s: %w", ErrDNS, target, err)
+	case isTLSError(err):
+		return fmt.Errorf("%w: %s: %w", ErrTLS, target, err)
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return fmt.Errorf("%w: %s: %w", ErrTimeout, target, err)
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return fmt.Errorf("%w: %s: %w", ErrConnectionRefused, target, err)
+	}
+	return err
+}
+
+func isTLSError(err error) bool {
+	var (
+		verificationErr *tls.CertificateVerificationError
+		recordHeaderErr tls.RecordHeaderError
+		alertErr        tls.AlertError
+		unknownAuthErr  x509.UnknownAuthorityError
+		hostnameErr     x509.HostnameError
+		invalidErr      x509.CertificateInvalidError
+	)
+	return errors.As(err, &verificationErr) ||
+		errors.As(err, &recordHeaderErr) ||
+		errors.As(err, &alertErr) ||
+		errors.As(err, &unknownAuthErr) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidErr)
+}
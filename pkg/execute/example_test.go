@@ -0,0 +1,56 @@
+package execute_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+// Example_concurrentQueries shares one Client between many goroutines,
+// run it with go test -race to check that the state of the options is synchronized.
+func Example_concurrentQueries() {
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1)%4 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, `{"data":{"id":%q}}`, r.URL.Query().Get("wg_variables"))
+	}))
+	defer srv.Close()
+
+	c := execute.New(srv.Client(), srv.URL,
+		execute.WithRetry(5, execute.ExponentialBackoffWithJitter(time.Millisecond, 10*time.Millisecond)),
+		execute.WithCircuitBreaker(100, time.Second),
+		execute.WithCache(execute.NewMemoryCache()),
+		execute.WithSingleFlight(),
+	)
+
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int64
+	)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var response struct {
+				ID string `json:"id"`
+			}
+			if err := c.Query(context.Background(), "/operations/Item", map[string]int{"id": i % 10}, &response); err != nil {
+				fmt.Println(err)
+				return
+			}
+			succeeded.Add(1)
+		}(i)
+	}
+	wg.Wait()
+	fmt.Println(succeeded.Load(), "queries succeeded")
+	// Output: 100 queries succeeded
+}
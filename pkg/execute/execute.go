@@ -1,223 +1,540 @@
 package execute
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"time"
 )
 
-func Query[Input any, Response any](client *http.Client, ctx context.Context, baseURL, path string, input *Input) (response *Response, err error) {
-	baseUrlWithPath := baseURL + path
-	if input != nil {
-		variables, err := json.Marshal(input)
-		if err != nil {
-			return nil, err
-		}
-		baseUrlWithPath = baseUrlWithPath + "?wg_variables=" + url.QueryEscape(string(variables))
-	}
-	req, err := http.NewRequestWithContext(ctx, "GET", baseUrlWithPath, nil)
-	if err != nil {
+// operationTypeHeader tells the server which kind of operation a POST request carries
+const operationTypeHeader = "X-WG-Operation-Type"
+
+// Result carries the decoded response together with metadata of the HTTP response
+// Response doesn't need to be a concrete type, json.RawMessage returns the data undecoded, map[string]any or any decode it generically.
+// Either way, GraphQL errors are returned as *GraphQLError and a null or missing data field results in a nil Data.
+// Untyped numbers are decoded as float64, prefer json.RawMessage to keep large integers like IDs exact.
+type Result[Response any] struct {
+	StatusCode int
+	Headers    http.Header
+	Data       *Response
+	// RequestID is the ID sent with WithRequestID
+	RequestID string
+	// Extensions is the extensions object of the response, e.g. tracing information added by the server
+	Extensions json.RawMessage
+}
+
+func Query[Input any, Response any](client *http.Client, ctx context.Context, baseURL, path string, input *Input, opts ...Option) (*Response, error) {
+	result, err := QueryWithResponse[Input, Response](client, ctx, baseURL, path, input, opts...)
+	if result == nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	res, err := client.Do(req)
+	return result.Data, err
+}
+
+func QueryWithResponse[Input any, Response any](client *http.Client, ctx context.Context, baseURL, path string, input *Input, opts ...Option) (*Result[Response], error) {
+	return query[Response](New(client, baseURL), ctx, path, input, newOptions(opts))
+}
+
+// QueryRaw is like Query, but also returns the data of the response undecoded, e.g. to pass it on verbatim
+func QueryRaw[Input any, Response any](client *http.Client, ctx context.Context, baseURL, path string, input *Input, opts ...Option) (json.RawMessage, *Response, error) {
+	o := newOptions(opts)
+	result, err := query[json.RawMessage](New(client, baseURL), ctx, path, input, o)
+	if result == nil || result.Data == nil {
+		return nil, nil, err
+	}
+	var response Response
+	return *result.Data, &response, decodeInto(result, err, &response, o)
+}
+
+func query[Response any](c *Client, ctx context.Context, path string, input any, o *options) (*Result[Response], error) {
+	key, newRequest, err := newQueryRequest(c, path, input, o)
 	if err != nil {
-		if _, ok := err.(*url.Error); ok {
-			return nil, fmt.Errorf("connection refused: %s://%s", req.URL.Scheme, req.URL.Host)
-		}
 		return nil, err
 	}
-	if res.StatusCode == http.StatusOK {
-		defer res.Body.Close()
-		err = json.NewDecoder(res.Body).Decode(&response)
-		return response, nil
-	}
-	if res.StatusCode == http.StatusBadRequest {
-		return nil, errors.New("bad request")
-	}
-	if res.StatusCode == http.StatusUnauthorized {
-		return nil, errors.New("unauthorized")
-	}
-	if res.StatusCode == http.StatusInternalServerError {
-		return nil, errors.New("internal server error")
-	}
-	return nil, errors.New("unknown error")
+	return doOperation[Response](c, ctx, o, OperationQuery, path, true, key, newRequest)
 }
 
-func Mutate[Input any, Response any](client *http.Client, ctx context.Context, baseURL, path string, input *Input) (response *Response, err error) {
-	baseUrlWithPath := baseURL + path
+// newQueryRequest encodes input and returns a func creating the request of the query,
+// key is set for GET requests, see doOperation
+func newQueryRequest(c *Client, path string, input any, o *options) (key string, newRequest func(ctx context.Context) (*http.Request, error), err error) {
 	var (
-		body *bytes.Buffer
+		variables []byte
 	)
-	if input != nil {
-		body = &bytes.Buffer{}
-		err = json.NewEncoder(body).Encode(input)
+	if err := o.validateInput(input); err != nil {
+		return "", nil, err
+	}
+	if hasInput(input) {
+		variables, err = o.encodeInput(input)
 		if err != nil {
-			return nil, errors.New("error encoding input")
+			return "", nil, fmt.Errorf("%w: %w", ErrEncodingInput, err)
 		}
 	}
-	req, err := http.NewRequestWithContext(ctx, "POST", baseUrlWithPath, body)
+	params := o.urlParams()
+	o.setOperationHash(params)
+	if variables != nil && !o.postQuery {
+		params.Set(o.variablesParam, string(variables))
+	}
+	baseUrlWithPath, err := c.operationURL(o, path, params)
 	if err != nil {
+		return "", nil, err
+	}
+	post := o.postQuery
+	if !post && variables != nil && o.urlLengthThreshold > 0 && len(baseUrlWithPath) > o.urlLengthThreshold {
+		// the URL might be rejected with 414 Request-URI Too Large, send the variables in the body instead
+		post = true
+		params.Del(o.variablesParam)
+		if baseUrlWithPath, err = c.operationURL(o, path, params); err != nil {
+			return "", nil, err
+		}
+	}
+	method, body := "GET", []byte(nil)
+	if post {
+		method, body = "POST", variables
+	} else {
+		key = baseUrlWithPath
+	}
+	return key, func(ctx context.Context) (*http.Request, error) {
+		req, err := o.newRequest(ctx, method, baseUrlWithPath, body)
+		if err != nil {
+			return nil, err
+		}
+		if post {
+			req.Header.Set(operationTypeHeader, "query")
+		}
+		return req, nil
+	}, nil
+}
+
+func Mutate[Input any, Response any](client *http.Client, ctx context.Context, baseURL, path string, input *Input, opts ...Option) (*Response, error) {
+	result, err := MutateWithResponse[Input, Response](client, ctx, baseURL, path, input, opts...)
+	if result == nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	res, err := client.Do(req)
+	return result.Data, err
+}
+
+func MutateWithResponse[Input any, Response any](client *http.Client, ctx context.Context, baseURL, path string, input *Input, opts ...Option) (*Result[Response], error) {
+	return mutate[Response](New(client, baseURL), ctx, path, input, newOptions(opts))
+}
+
+func mutate[Response any](c *Client, ctx context.Context, path string, input any, o *options) (*Result[Response], error) {
+	params := url.Values{}
+	o.setOperationHash(params)
+	baseUrlWithPath, err := c.operationURL(o, path, params)
 	if err != nil {
-		if _, ok := err.(*url.Error); ok {
-			return nil, fmt.Errorf("connection refused: %s://%s", req.URL.Scheme, req.URL.Host)
-		}
 		return nil, err
 	}
-	if res.StatusCode == http.StatusOK {
-		defer res.Body.Close()
-		err = json.NewDecoder(res.Body).Decode(&response)
-		return response, nil
+	method, err := o.mutationMethod()
+	if err != nil {
+		return nil, err
 	}
-	if res.StatusCode == http.StatusBadRequest {
-		return nil, errors.New("bad request")
+	var (
+		body []byte
+	)
+	if err := o.validateInput(input); err != nil {
+		return nil, err
 	}
-	if res.StatusCode == http.StatusUnauthorized {
-		return nil, errors.New("unauthorized")
+	if hasInput(input) {
+		body, err = o.encodeInput(input)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrEncodingInput, err)
+		}
 	}
-	if res.StatusCode == http.StatusInternalServerError {
-		return nil, errors.New("internal server error")
+	// the body is compressed once, so that retries can reuse it
+	compressed := o.shouldCompressRequest(body)
+	if compressed {
+		body, err = gzipBody(body)
+		if err != nil {
+			return nil, err
+		}
 	}
-	return nil, errors.New("unknown error")
+	idempotencyKey := o.newIdempotencyKey()
+	return doOperation[Response](c, ctx, o, OperationMutation, path, o.idempotent, "", func(ctx context.Context) (*http.Request, error) {
+		req, err := o.newRequest(ctx, method, baseUrlWithPath, body)
+		if err != nil {
+			return nil, err
+		}
+		if idempotencyKey != "" {
+			req.Header.Set(idempotencyKeyHeader, idempotencyKey)
+		}
+		if compressed {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+		if err := o.setCSRFToken(req); err != nil {
+			return nil, err
+		}
+		return req, nil
+	})
 }
 
-func LiveQuery[Input any, Response any](client *http.Client, ctx context.Context, baseURL, path string, input *Input) (*Stream[Response], error) {
-	return buildStream[Input, Response](client, ctx, baseURL, path, true, input)
+// Do sends req and handles the response like Query and Mutate, for requests which they can't build.
+// Options modifying requests, e.g. WithHeader, aren't applied to req, but it's traced like other requests.
+// GET requests, and others with WithIdempotent, are retried if their body is empty or can be replayed with GetBody.
+func Do[Response any](client *http.Client, ctx context.Context, req *http.Request, opts ...Option) (*Response, error) {
+	o := newOptions(opts)
+	operation := OperationMutation
+	if req.Method == http.MethodGet {
+		operation = OperationQuery
+	}
+	retry := replayable(req) && (req.Method == http.MethodGet || o.idempotent)
+	attempt := 0
+	result, err := doOperation[Response](New(client, ""), ctx, o, operation, req.URL.Path, retry, "", func(ctx context.Context) (*http.Request, error) {
+		r := req.Clone(ctx)
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r.Body = body
+		}
+		attempt++
+		if o.tracer != nil {
+			o.tracer.Inject(ctx, r.Header)
+		}
+		return r, nil
+	})
+	if result == nil {
+		return nil, err
+	}
+	return result.Data, err
 }
 
-func Subscribe[Input any, Response any](client *http.Client, ctx context.Context, baseURL, path string, input *Input) (*Stream[Response], error) {
-	return buildStream[Input, Response](client, ctx, baseURL, path, false, input)
+// doOperation sends the request created by newRequest and decodes the result.
+// It applies the timeout and records the operation with the configured Tracer and Observer.
+// A non-empty key identifies GET queries, which can be coalesced with WithSingleFlight and cached with WithCache.
+func doOperation[Response any](c *Client, ctx context.Context, o *options, operation, path string, retry bool, key string, newRequest func(ctx context.Context) (*http.Request, error)) (*Result[Response], error) {
+	requestID := o.newRequestID()
+	result, err := doRequest(c, ctx, o, operation, path, retry, key, requestID, newRequest, decodeResult[Response])
+	if result != nil {
+		result.RequestID = requestID
+	}
+	return result, err
 }
 
-func buildStream[Input any, Response any](client *http.Client, ctx context.Context, baseURL, path string, liveQuery bool, input *Input) (*Stream[Response], error) {
-	baseUrlWithPath := baseURL + path
-	if input != nil {
-		variables, err := json.Marshal(input)
-		if err != nil {
-			return nil, err
+// doRequest is doOperation with a custom decode func for responses which aren't a single responseEnvelope
+func doRequest[T any](c *Client, ctx context.Context, o *options, operation, path string, retry bool, key, requestID string, newRequest func(ctx context.Context) (*http.Request, error), decode func(res *http.Response, o *options) (T, error)) (result T, err error) {
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+	ctx, span := o.startSpan(ctx, operation, path)
+	start, statusCode := time.Now(), 0
+	var (
+		res       *http.Response
+		attempts  int
+		fromCache bool
+	)
+	defer func() {
+		span.End(err)
+		o.observeRequest(operation, path, statusCode, start)
+		if o.onMetadata != nil {
+			o.onMetadata(RequestMetadata{Duration: time.Since(start), Attempts: attempts, FromCache: fromCache})
+		}
+	}()
+	sendRequest := func(newRequest func() (*http.Request, error)) (res *http.Response, err error) {
+		if c.breaker != nil {
+			done, allowErr := c.breaker.allow(o, path)
+			if allowErr != nil {
+				return nil, allowErr
+			}
+			defer func() {
+				done(res, err)
+			}()
 		}
-		baseUrlWithPath = baseUrlWithPath + "?wg_variables=" + url.QueryEscape(string(variables))
-		if liveQuery {
-			baseUrlWithPath += "&wg_live=true"
+		if key != "" && c.flight != nil {
+			return c.sendShared(ctx, o, key, retry, newRequest)
 		}
-	} else if liveQuery {
-		baseUrlWithPath = baseUrlWithPath + "?wg_live=true"
+		return send(c.httpClientFor(o), ctx, o, retry, newRequest)
+	}
+	newRequestWithContext := func() (*http.Request, error) {
+		attempts++
+		req, err := newRequest(ctx)
+		if err == nil && requestID != "" {
+			req.Header.Set(requestIDHeader, requestID)
+		}
+		return req, err
+	}
+	if key != "" && o.cache != nil {
+		res, fromCache, err = sendCached(ctx, o, key, newRequestWithContext, sendRequest)
+	} else {
+		res, err = sendRequest(newRequestWithContext)
 	}
-	req, err := http.NewRequestWithContext(ctx, "GET", baseUrlWithPath, nil)
 	if err != nil {
-		return nil, err
+		return result, err
+	}
+	statusCode = res.StatusCode
+	span.SetStatusCode(res.StatusCode)
+	return decode(res, o)
+}
+
+func decodeResult[Response any](res *http.Response, o *options) (*Result[Response], error) {
+	defer closeOnCancel(res)()
+	if res.StatusCode == http.StatusNotModified {
+		// conditional requests, the ETag is available in the headers of the result
+		_ = res.Body.Close()
+		return &Result[Response]{
+			StatusCode: res.StatusCode,
+			Headers:    res.Header,
+		}, ErrNotModified
+	}
+	if !isSuccess(res.StatusCode) {
+		return nil, newAPIError(res, o.errorCodes)
+	}
+	defer res.Body.Close()
+	result := &Result[Response]{
+		StatusCode: res.StatusCode,
+		Headers:    res.Header,
+	}
+	if res.StatusCode == http.StatusNoContent {
+		return result, nil
+	}
+	if unmarshal := o.unmarshalerFor(res.Header); unmarshal != nil {
+		return decodeUnmarshaled(result, res, o, unmarshal)
+	}
+	var envelope responseEnvelope[Response]
+	err := newDecoder(o.codec, responseBody(res, o), o.strictDecoding).Decode(&envelope)
+	if err == io.EOF {
+		// empty body, e.g. 202 Accepted
+		return result, nil
+	}
+	if errors.Is(err, ErrResponseTooLarge) {
+		return nil, ErrResponseTooLarge
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	res, err := client.Do(req)
 	if err != nil {
-		if _, ok := err.(*url.Error); ok {
-			return nil, fmt.Errorf("connection refused: %s://%s", req.URL.Scheme, req.URL.Host)
+		if res.Request != nil && res.Request.Context().Err() != nil {
+			// the body was closed by closeOnCancel
+			err = res.Request.Context().Err()
 		}
-		return nil, err
+		if o.strictDecoding {
+			return nil, fmt.Errorf("strict decoding: %w", err)
+		}
+		return nil, fmt.Errorf("error decoding response: %w", err)
 	}
-	if res.StatusCode == http.StatusOK {
-		return &Stream[Response]{
-			body:   res.Body,
-			reader: bufio.NewReader(res.Body),
-			buf:    &bytes.Buffer{},
-		}, nil
+	result.Data = envelope.Data
+	result.Extensions = envelope.Extensions
+	if hasJSONValue(envelope.Extensions) && o.onExtensions != nil {
+		o.onExtensions(envelope.Extensions)
 	}
-	if res.StatusCode == http.StatusBadRequest {
-		return nil, errors.New("bad request")
+	if len(envelope.Errors) != 0 {
+		return result, newGraphQLError(envelope.Errors, o.errorCodes)
 	}
-	if res.StatusCode == http.StatusUnauthorized {
-		return nil, errors.New("unauthorized")
+	return result, nil
+}
+
+// closeOnCancel closes the body of res once the context of its request is done, so that decoding a large or slow response
+// stops when the caller gives up, also if a custom RoundTripper doesn't bind the body to the context. stop must be called afterwards.
+func closeOnCancel(res *http.Response) (stop func() bool) {
+	if res.Request == nil {
+		return func() bool { return false }
 	}
-	if res.StatusCode == http.StatusInternalServerError {
-		return nil, errors.New("internal server error")
+	return context.AfterFunc(res.Request.Context(), func() {
+		_ = res.Body.Close()
+	})
+}
+
+// responseBody applies the limit set with WithMaxResponseBytes to the body of res
+func responseBody(res *http.Response, o *options) io.Reader {
+	if o.maxResponseBytes > 0 {
+		return &maxBytesReader{r: res.Body, remaining: o.maxResponseBytes}
 	}
-	return nil, errors.New("unknown error")
+	return res.Body
 }
 
-type Stream[Response any] struct {
-	body   io.ReadCloser
-	reader *bufio.Reader
-	buf    *bytes.Buffer
+// maxBytesReader fails with ErrResponseTooLarge once more than remaining bytes are read
+type maxBytesReader struct {
+	r         io.Reader
+	remaining int64
 }
 
-func (s *Stream[Response]) Close() error {
-	if s == nil || s.body == nil {
-		return nil
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	if m.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	// read one byte more than allowed to detect bodies exceeding the limit
+	if int64(len(p)) > m.remaining+1 {
+		p = p[:m.remaining+1]
+	}
+	n, err := m.r.Read(p)
+	m.remaining -= int64(n)
+	if m.remaining < 0 {
+		return 0, ErrResponseTooLarge
 	}
-	return s.body.Close()
+	return n, err
 }
 
-func (s *Stream[Response]) Next(ctx context.Context) (res *Response, closed bool, err error) {
-	defer func() {
-		// if we cancel the context, the server can close the stream while sending the next response
-		// this might lead to unexpected errors which we'd like to catch, because it would be unexpected
-		// this defer func simply cleans up the return values in case of a context cancelation
-		if ctx.Err() != nil {
-			err = nil
-			closed = true
+// hasJSONValue reports whether an optional field was set
+func hasJSONValue(raw json.RawMessage) bool {
+	return len(raw) != 0 && string(raw) != "null"
+}
+
+// responseEnvelope is the JSON document returned by the WunderGraph server
+type responseEnvelope[Response any] struct {
+	Data       *Response           `json:"data"`
+	Errors     []GraphQLErrorEntry `json:"errors"`
+	Extensions json.RawMessage     `json:"extensions"`
+}
+
+func LiveQuery[Input any, Response any](client *http.Client, ctx context.Context, baseURL, path string, input *Input, opts ...Option) (*Stream[Response], error) {
+	return buildStream[Response](New(client, baseURL), ctx, path, true, input, newOptions(opts))
+}
+
+func Subscribe[Input any, Response any](client *http.Client, ctx context.Context, baseURL, path string, input *Input, opts ...Option) (*Stream[Response], error) {
+	return buildStream[Response](New(client, baseURL), ctx, path, false, input, newOptions(opts))
+}
+
+func buildStream[Response any](c *Client, ctx context.Context, path string, liveQuery bool, input any, o *options) (*Stream[Response], error) {
+	params := o.urlParams()
+	if err := o.validateInput(input); err != nil {
+		return nil, err
+	}
+	if err := resolveDedupeFunc[Response](o); err != nil {
+		return nil, err
+	}
+	method, body := "GET", []byte(nil)
+	if o.postQuery {
+		method = "POST"
+	}
+	if hasInput(input) {
+		variables, err := o.encodeInput(input)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrEncodingInput, err)
+		}
+		if o.postQuery {
+			body = variables
+		} else {
+			params.Set(o.variablesParam, string(variables))
 		}
-	}()
-	if s == nil || s.buf == nil || s.reader == nil {
-		_ = s.Close()
-		return nil, true, errors.New("stream is closed")
 	}
-	s.buf.Reset()
-	var (
-		lastByteIsNewLine = false
-	)
-	for {
-		if err := ctx.Err(); err != nil {
-			// context canceled, stop reading
-			_ = s.Close()
-			return nil, true, nil
+	if liveQuery {
+		if o.liveHeader == "" {
+			params.Set(o.liveParam, "true")
 		}
-		b, err := s.reader.ReadByte()
-		if err != nil {
-			_ = s.Close()
-			return nil, true, errors.New("unexpected end of stream")
-		}
-		if b == '\n' {
-			// potential end of message
-			if lastByteIsNewLine {
-				// end of message detected (\n\n)
-				var response Response
-				err = json.NewDecoder(s.buf).Decode(&response)
-				if err != nil {
-					_ = s.Close()
-					return nil, true, errors.New("error reading JSON")
-				}
-				return &response, false, nil
+		if o.jsonPatch {
+			params.Set(jsonPatchParam, "true")
+		}
+	}
+	if o.sse {
+		params.Set("wg_sse", "true")
+	}
+	baseUrlWithPath, err := c.operationURL(o, path, params)
+	if err != nil {
+		return nil, err
+	}
+	operation, operationType := OperationSubscription, "subscription"
+	if liveQuery {
+		operation, operationType = OperationLiveQuery, "query"
+	}
+	ctx, span := o.startSpan(ctx, operation, path)
+	requestID := o.newRequestID()
+	open := func(ctx context.Context) (*http.Response, context.CancelFunc, error) {
+		// the request context must outlive this function, because it is bound to the response body,
+		// WithTimeout therefore only cancels it if the stream couldn't be established in time
+		ctx, cancel := context.WithCancel(ctx)
+		var (
+			timer      *time.Timer
+			start      = time.Now()
+			statusCode = 0
+		)
+		defer func() {
+			o.observeRequest(operation, path, statusCode, start)
+		}()
+		if o.timeout > 0 {
+			timer = time.AfterFunc(o.timeout, cancel)
+		}
+		var (
+			done func(res *http.Response, err error)
+		)
+		if c.breaker != nil {
+			var err error
+			if done, err = c.breaker.allow(o, path); err != nil {
+				cancel()
+				return nil, nil, err
 			}
-			// note that we have a newline
-			lastByteIsNewLine = true
-			continue
 		}
-		if lastByteIsNewLine {
-			// only single newline, write to buffer
-			err = s.buf.WriteByte('\n')
+		res, err := send(c.httpClientFor(o), ctx, o, false, func() (*http.Request, error) {
+			req, err := o.newRequest(ctx, method, baseUrlWithPath, body)
 			if err != nil {
-				_ = s.Close()
-				return nil, true, errors.New("buffer overflow")
+				return nil, err
+			}
+			if o.postQuery {
+				req.Header.Set(operationTypeHeader, operationType)
 			}
+			if o.sse {
+				o.setAccept(req, "text/event-stream")
+			}
+			if requestID != "" {
+				req.Header.Set(requestIDHeader, requestID)
+			}
+			if liveQuery && o.liveHeader != "" {
+				req.Header.Set(o.liveHeader, "true")
+			}
+			return req, nil
+		})
+		if err == nil {
+			statusCode = res.StatusCode
+		}
+		if done != nil {
+			done(res, err)
+		}
+		if timer != nil && !timer.Stop() {
+			if err == nil {
+				_ = res.Body.Close()
+			}
+			cancel()
+			return nil, nil, fmt.Errorf("establishing stream: %w", context.DeadlineExceeded)
 		}
-		lastByteIsNewLine = false
-		err = s.buf.WriteByte(b)
 		if err != nil {
-			_ = s.Close()
-			return nil, true, errors.New("buffer overflow")
+			cancel()
+			return nil, nil, err
+		}
+		span.SetStatusCode(res.StatusCode)
+		if liveQuery && o.canPoll(res) {
+			apiErr := newAPIError(res, o.errorCodes)
+			if o.onStreamEvent != nil {
+				o.onStreamEvent(StreamPollingFallback{Err: apiErr})
+			}
+			if res, err = pollQuery(c, ctx, path, input, o); err != nil {
+				cancel()
+				return nil, nil, err
+			}
+			return res, cancel, nil
 		}
+		if !isSuccess(res.StatusCode) {
+			cancel()
+			if o.postQuery && res.StatusCode == http.StatusMethodNotAllowed {
+				return nil, nil, fmt.Errorf("%w: %w", ErrPostStreamNotSupported, newAPIError(res, o.errorCodes))
+			}
+			return nil, nil, newAPIError(res, o.errorCodes)
+		}
+		return res, cancel, nil
+	}
+	res, cancel, err := open(ctx)
+	if err != nil {
+		span.End(err)
+		return nil, err
+	}
+	span.AddEvent("connected")
+	if o.logger != nil {
+		o.logger.LogAttrs(ctx, slog.LevelDebug, "stream opened", slog.String("operation", operation), slog.String("path", path))
+	}
+	stream := newStream[Response](ctx, res, cancel, o)
+	stream.span = span
+	stream.requestID = requestID
+	if observer, ok := o.observer.(StreamObserver); ok {
+		stream.observer = observer
+		stream.operation, stream.path = operation, path
+	}
+	if o.reconnect != nil {
+		stream.open = open
+		stream.reconnect = o.reconnect
 	}
+	stream.emit(StreamConnected{})
+	return stream, nil
 }
@@ -0,0 +1,300 @@
+package execute_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestQueryCancelDuringSlowBody(t *testing.T) {
+	stalled := func(t *testing.T) *execute.Client {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"data":{"items":[`))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}))
+		t.Cleanup(srv.Close)
+		return execute.New(srv.Client(), srv.URL)
+	}
+	// ignoringContext returns a body which doesn't observe the context of the request
+	ignoringContext := func(t *testing.T) *execute.Client {
+		return execute.New(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			pr, pw := io.Pipe()
+			go func() {
+				_, _ = pw.Write([]byte(`{"data":{"items":[`))
+			}()
+			t.Cleanup(func() {
+				_ = pw.Close()
+			})
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: pr, Request: req}, nil
+		})}, "http://localhost:9991")
+	}
+	for name, newClient := range map[string]func(t *testing.T) *execute.Client{"server": stalled, "transport ignoring context": ignoringContext} {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+			done := make(chan error, 1)
+			go func() {
+				var response map[string]any
+				done <- newClient(t).Query(ctx, "/operations/Items", nil, &response)
+			}()
+			select {
+			case err := <-done:
+				if !errors.Is(err, context.Canceled) {
+					t.Fatalf("expected context.Canceled, got %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Query didn't return after the context was canceled")
+			}
+		})
+	}
+}
+
+// newCountingServer responds with the status codes in turn, the last one is repeated, attempts counts the requests
+func newCountingServer(t *testing.T, statusCodes ...int) (srv *httptest.Server, attempts *atomic.Int64) {
+	t.Helper()
+	attempts = &atomic.Int64{}
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(attempts.Add(1))
+		w.WriteHeader(statusCodes[min(n, len(statusCodes))-1])
+		_, _ = w.Write([]byte(`{"data":{}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, attempts
+}
+
+func TestQueryRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		statusCodes  []int
+		wantAttempts int64
+		wantStatus   int
+	}{
+		{name: "succeeds", statusCodes: []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK}, wantAttempts: 3},
+		{name: "gives up", statusCodes: []int{http.StatusServiceUnavailable}, wantAttempts: 3, wantStatus: http.StatusServiceUnavailable},
+		{name: "not retryable", statusCodes: []int{http.StatusInternalServerError, http.StatusOK}, wantAttempts: 1, wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, attempts := newCountingServer(t, tt.statusCodes...)
+			c := execute.New(srv.Client(), srv.URL, execute.WithRetry(3, execute.ConstantBackoff(time.Millisecond)))
+			err := c.Query(context.Background(), "/operations/Items", nil, nil)
+			var apiErr *execute.APIError
+			if tt.wantStatus == 0 && err != nil || tt.wantStatus != 0 && (!errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantStatus) {
+				t.Fatalf("expected status %d, got %v", tt.wantStatus, err)
+			}
+			if n := attempts.Load(); n != tt.wantAttempts {
+				t.Fatalf("expected %d attempts, got %d", tt.wantAttempts, n)
+			}
+		})
+	}
+}
+
+func TestMutateRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []execute.Option
+		wantAttempts int64
+	}{
+		{name: "not idempotent", wantAttempts: 1},
+		{name: "idempotent", opts: []execute.Option{execute.WithIdempotent()}, wantAttempts: 3},
+		{name: "idempotency key", opts: []execute.Option{execute.WithIdempotencyKey("key")}, wantAttempts: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, attempts := newCountingServer(t, http.StatusServiceUnavailable)
+			c := execute.New(srv.Client(), srv.URL, execute.WithRetry(3, execute.ConstantBackoff(time.Millisecond)))
+			if err := c.Mutate(context.Background(), "/operations/CreateItem", nil, nil, tt.opts...); err == nil {
+				t.Fatal("expected an error")
+			}
+			if n := attempts.Load(); n != tt.wantAttempts {
+				t.Fatalf("expected %d attempts, got %d", tt.wantAttempts, n)
+			}
+		})
+	}
+}
+
+func TestQueryRetryPerAttemptTimeout(t *testing.T) {
+	var attempts atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			// the first attempt hangs until the client gives up
+			<-r.Context().Done()
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"id":1}}`))
+	}))
+	defer srv.Close()
+	c := execute.New(srv.Client(), srv.URL, execute.WithRetry(2, execute.ConstantBackoff(time.Millisecond)), execute.WithPerAttemptTimeout(50*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var response struct {
+		ID int `json:"id"`
+	}
+	if err := c.Query(ctx, "/operations/Item", nil, &response); err != nil || response.ID != 1 {
+		t.Fatalf("expected the second attempt to succeed, got %v, err %v", response, err)
+	}
+	if n := attempts.Load(); n != 2 {
+		t.Fatalf("expected 2 attempts, got %d", n)
+	}
+}
+
+func TestQueryRetryCanceledDuringBackoff(t *testing.T) {
+	srv, attempts := newCountingServer(t, http.StatusServiceUnavailable)
+	c := execute.New(srv.Client(), srv.URL, execute.WithRetry(3, execute.ConstantBackoff(time.Minute)))
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	err := c.Query(ctx, "/operations/Items", nil, nil)
+	if !errors.Is(err, context.Canceled) || time.Since(start) > 5*time.Second {
+		t.Fatalf("expected the call to return once ctx is canceled, got %v after %v", err, time.Since(start))
+	}
+	if n := attempts.Load(); n != 1 {
+		t.Fatalf("expected 1 attempt, got %d", n)
+	}
+}
+
+// newRateLimitedServer responds with 429 and retryAfter to the first `limited` requests and succeeds afterwards
+func newRateLimitedServer(t *testing.T, retryAfter string, limited int64) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	attempts := &atomic.Int64{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) <= limited {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, attempts
+}
+
+func TestQueryRateLimited(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		min, max   time.Duration
+	}{
+		{name: "seconds", retryAfter: "120", min: 120 * time.Second, max: 120 * time.Second},
+		{name: "HTTP date", retryAfter: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), min: 58 * time.Minute, max: time.Hour},
+		{name: "past HTTP date", retryAfter: time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)},
+		{name: "invalid", retryAfter: "soon"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newRateLimitedServer(t, tt.retryAfter, 1)
+			err := execute.New(srv.Client(), srv.URL).Query(context.Background(), "/operations/Items", nil, nil)
+			var apiErr *execute.APIError
+			if !errors.Is(err, execute.ErrRateLimited) || !errors.As(err, &apiErr) {
+				t.Fatalf("expected ErrRateLimited, got %v", err)
+			}
+			if apiErr.RetryAfter < tt.min || apiErr.RetryAfter > tt.max {
+				t.Fatalf("expected RetryAfter between %v and %v, got %v", tt.min, tt.max, apiErr.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestQueryRetryAfterTakesPrecedenceOverBackoff(t *testing.T) {
+	t.Run("shorter", func(t *testing.T) {
+		srv, attempts := newRateLimitedServer(t, "0", 1)
+		c := execute.New(srv.Client(), srv.URL, execute.WithRetry(2, execute.ConstantBackoff(time.Minute)))
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := c.Query(ctx, "/operations/Items", nil, nil); err != nil || attempts.Load() != 2 {
+			t.Fatalf("expected the retry to ignore the backoff, got %d attempts, err %v", attempts.Load(), err)
+		}
+	})
+	t.Run("longer", func(t *testing.T) {
+		srv, attempts := newRateLimitedServer(t, "1", 1)
+		c := execute.New(srv.Client(), srv.URL, execute.WithRetry(2, execute.ConstantBackoff(time.Millisecond)))
+		start := time.Now()
+		if err := c.Query(context.Background(), "/operations/Items", nil, nil); err != nil || attempts.Load() != 2 {
+			t.Fatalf("expected 2 attempts, got %d, err %v", attempts.Load(), err)
+		}
+		if d := time.Since(start); d < time.Second {
+			t.Fatalf("expected the retry to wait for Retry-After, it waited %v", d)
+		}
+	})
+	t.Run("final 429", func(t *testing.T) {
+		srv, attempts := newRateLimitedServer(t, "0", 3)
+		c := execute.New(srv.Client(), srv.URL, execute.WithRetry(2, execute.ConstantBackoff(time.Millisecond)))
+		if err := c.Query(context.Background(), "/operations/Items", nil, nil); !errors.Is(err, execute.ErrRateLimited) || attempts.Load() != 2 {
+			t.Fatalf("expected ErrRateLimited after 2 attempts, got %d attempts, err %v", attempts.Load(), err)
+		}
+	})
+}
+
+func TestQueryTokenRefresh(t *testing.T) {
+	errRefresh := errors.New("refresh failed")
+	tests := []struct {
+		name         string
+		refreshErr   error
+		newToken     string
+		wantRequests int32
+		wantErr      error
+	}{
+		{name: "repeated", newToken: "new", wantRequests: 2},
+		{name: "rejected again", newToken: "expired", wantRequests: 2, wantErr: execute.ErrUnauthorized},
+		{name: "refresh fails", refreshErr: errRefresh, wantRequests: 1, wantErr: errRefresh},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				if r.Header.Get("Authorization") != "Bearer new" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				_, _ = w.Write([]byte(`{"data":{}}`))
+			}))
+			defer srv.Close()
+			var (
+				token     atomic.Value
+				refreshed atomic.Int32
+			)
+			token.Store("expired")
+			c := execute.New(srv.Client(), srv.URL,
+				execute.WithBearerTokenProvider(func() (string, error) {
+					return token.Load().(string), nil
+				}),
+				execute.WithTokenRefresh(func(ctx context.Context) error {
+					refreshed.Add(1)
+					if tt.refreshErr != nil {
+						return tt.refreshErr
+					}
+					token.Store(tt.newToken)
+					return nil
+				}),
+			)
+			err := c.Query(context.Background(), "/operations/Items", nil, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, execute.ErrUnauthorized) {
+				t.Fatalf("expected ErrUnauthorized, got %v", err)
+			}
+			if n := requests.Load(); n != tt.wantRequests {
+				t.Fatalf("expected %d requests, got %d", tt.wantRequests, n)
+			}
+			if n := refreshed.Load(); n != 1 {
+				t.Fatalf("expected the token to be refreshed once, got %d", n)
+			}
+		})
+	}
+}
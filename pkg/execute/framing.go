@@ -0,0 +1,159 @@
+package execute
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+var (
+	// errEndOfStream is returned by the frame readers if the underlying connection ended unexpectedly
+	errEndOfStream = errors.New("end of stream")
+	// errStreamEnded is returned by the frame readers if the server ended the stream after a complete message
+	errStreamEnded = errors.New("stream ended")
+)
+
+// readError maps a failed read of the response body to errEndOfStream, failed queries of WithPollingFallback are kept
+func readError(err error) error {
+	var pollErr *pollError
+	if errors.As(err, &pollErr) {
+		return pollErr
+	}
+	return errEndOfStream
+}
+
+// readFrame reads the next \n\n delimited message into s.buf.
+// Lines are read in chunks, single newlines inside a message are kept.
+func (s *Stream[Response]) readFrame(ctx context.Context) error {
+	s.buf.Reset()
+	var (
+		lastByteIsNewLine = false
+	)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		chunk, err := s.reader.ReadSlice('\n')
+		if err == io.EOF && s.buf.Len() == 0 && len(bytes.TrimSpace(chunk)) == 0 {
+			return errStreamEnded
+		}
+		if err == io.EOF {
+			return s.endFrame(chunk, lastByteIsNewLine)
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			return readError(err)
+		}
+		content := bytes.TrimSuffix(chunk, []byte("\n"))
+		if len(content) == 0 && lastByteIsNewLine {
+			// end of message detected (\n\n)
+			return nil
+		}
+		if len(content) != 0 {
+			size := s.buf.Len() + len(content)
+			if lastByteIsNewLine {
+				size++
+			}
+			if size > s.maxFrameSize {
+				return ErrFrameTooLarge
+			}
+			if lastByteIsNewLine {
+				// only single newline, write to buffer
+				s.buf.WriteByte('\n')
+			}
+			s.buf.Write(content)
+		}
+		// ErrBufferFull returns a chunk without newline, the line continues with the next chunk
+		lastByteIsNewLine = len(content) != len(chunk)
+	}
+}
+
+// endFrame handles the connection ending without the \n\n terminating the last message,
+// the buffered message is still returned if it's complete, i.e. valid JSON
+func (s *Stream[Response]) endFrame(chunk []byte, lastByteIsNewLine bool) error {
+	if len(chunk) != 0 {
+		if s.buf.Len()+len(chunk) > s.maxFrameSize {
+			return ErrFrameTooLarge
+		}
+		if lastByteIsNewLine {
+			s.buf.WriteByte('\n')
+		}
+		s.buf.Write(chunk)
+	}
+	if !json.Valid(s.buf.Bytes()) {
+		return errEndOfStream
+	}
+	return nil
+}
+
+// readSSEFrame reads the next Server-Sent Event into s.buf.
+// Multiple data lines are joined with \n, comments are skipped and event, id and retry fields are ignored.
+func (s *Stream[Response]) readSSEFrame(ctx context.Context) error {
+	s.buf.Reset()
+	var (
+		hasData = false
+	)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line, err := s.readSSELine()
+		if err == errStreamEnded && hasData {
+			// the event wasn't dispatched, it's still returned if it's complete
+			if !json.Valid(s.buf.Bytes()) {
+				return errEndOfStream
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(line) == 0 {
+			// an empty line dispatches the event, events without data are returned as empty frames (heartbeats)
+			return nil
+		}
+		if line[0] == ':' {
+			// comment
+			continue
+		}
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		if string(field) != "data" {
+			continue
+		}
+		value = bytes.TrimPrefix(value, []byte(" "))
+		if hasData {
+			s.buf.WriteByte('\n')
+		}
+		s.buf.Write(value)
+		hasData = true
+		if s.buf.Len() > s.maxFrameSize {
+			return ErrFrameTooLarge
+		}
+	}
+}
+
+// readSSELine returns the next line without its line ending, the result is only valid until the next call
+func (s *Stream[Response]) readSSELine() ([]byte, error) {
+	s.line = s.line[:0]
+	for {
+		chunk, err := s.reader.ReadSlice('\n')
+		s.line = append(s.line, chunk...)
+		if len(s.line) > s.maxFrameSize {
+			return nil, ErrFrameTooLarge
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF && len(bytes.TrimSpace(s.line)) == 0 {
+			return nil, errStreamEnded
+		}
+		if err != nil && err != io.EOF {
+			return nil, readError(err)
+		}
+		// a line ended by EOF instead of a newline is complete too, the next read reports the end of the stream
+		line := bytes.TrimSuffix(s.line, []byte("\n"))
+		return bytes.TrimSuffix(line, []byte("\r")), nil
+	}
+}
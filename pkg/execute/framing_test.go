@@ -0,0 +1,133 @@
+package execute_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+func TestStreamLastFrameEndedByEOF(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        []string
+		wantErr     error
+	}{
+		{name: "complete", contentType: "application/json", body: `{"data":{"n":1}}` + "\n\n" + `{"data":{"n":2}}`, want: []string{`{"n":1}`, `{"n":2}`}},
+		{name: "single newline", contentType: "application/json", body: `{"data":{"n":1}}` + "\n", want: []string{`{"n":1}`}},
+		{name: "incomplete", contentType: "application/json", body: `{"data":{"n":1}}` + "\n\n" + `{"data":{"n":`, want: []string{`{"n":1}`}, wantErr: execute.ErrUnexpectedEndOfStream},
+		{name: "sse complete", contentType: "text/event-stream", body: `data: {"data":{"n":1}}` + "\n\n" + `data: {"data":{"n":2}}`, want: []string{`{"n":1}`, `{"n":2}`}},
+		{name: "sse incomplete", contentType: "text/event-stream", body: `data: {"data":{"n":1}}` + "\n\n" + `data: {"data":`, want: []string{`{"n":1}`}, wantErr: execute.ErrUnexpectedEndOfStream},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			stream, err := execute.New(srv.Client(), srv.URL).Subscribe(context.Background(), "/operations/Counter", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer stream.Close()
+			var got []string
+			for {
+				res, closed, err := stream.Next(context.Background())
+				if closed {
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+					}
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, string(*res))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// repeatBody endlessly repeats frame, filling every read completely like a fast server, reads counts the calls to Read
+type repeatBody struct {
+	frame []byte
+	off   int
+	reads int
+}
+
+func (r *repeatBody) Read(p []byte) (int, error) {
+	r.reads++
+	n := 0
+	for n < len(p) {
+		c := copy(p[n:], r.frame[r.off:])
+		n += c
+		r.off = (r.off + c) % len(r.frame)
+	}
+	return n, nil
+}
+
+func (r *repeatBody) Close() error {
+	return nil
+}
+
+// repeatClient returns a Client whose streams receive frame over and over again
+func repeatClient(frame string, contentType string) (*http.Client, *repeatBody) {
+	body := &repeatBody{frame: []byte(frame)}
+	return &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {contentType}}, Body: body, Request: req}, nil
+	})}, body
+}
+
+// multilineFrame returns a frame of about size bytes, whose data spans many lines
+func multilineFrame(size int) string {
+	var sb strings.Builder
+	sb.WriteString(`{"data":{"items":[`)
+	for i := 0; sb.Len() < size; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, "\n{\"id\":%d,\"name\":\"item %d\"}", i, i)
+	}
+	sb.WriteString("]}}\n\n")
+	return sb.String()
+}
+
+func BenchmarkStreamReadFrame(b *testing.B) {
+	for _, size := range []int{128, 4 << 10, 256 << 10} {
+		frame := multilineFrame(size)
+		for _, contentType := range []string{"application/json", "text/event-stream"} {
+			frame := frame
+			if contentType == "text/event-stream" {
+				frame = "data: " + strings.ReplaceAll(strings.TrimSuffix(frame, "\n\n"), "\n", "\ndata: ") + "\n\n"
+			}
+			b.Run(fmt.Sprintf("%s/%d", contentType, size), func(b *testing.B) {
+				client, _ := repeatClient(frame, contentType)
+				stream, err := execute.New(client, "http://localhost:9991").Subscribe(context.Background(), "/operations/Items", nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+				defer stream.Close()
+				b.SetBytes(int64(len(frame)))
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, closed, err := stream.Next(context.Background()); err != nil || closed {
+						b.Fatalf("closed %v, err %v", closed, err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package execute
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// defaultHealthPath is the health endpoint of the WunderGraph node
+const defaultHealthPath = "/health"
+
+// WithHealthPath sets the path Client.Ping requests, relative to the base URL. It defaults to /health.
+func WithHealthPath(path string) Option {
+	return func(o *options) {
+		o.healthPath = path
+	}
+}
+
+// Ping requests the health path of the server, see WithHealthPath, e.g. for readiness probes.
+// It returns nil if the server responds with 2xx, ErrUnhealthy for other responses and
+// errors like ErrConnectionRefused if the server is unreachable. Ping isn't retried.
+func (c *Client) Ping(ctx context.Context, opts ...Option) error {
+	o := c.options(opts)
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+	u, err := c.operationURL(o, o.healthPath, nil)
+	if err != nil {
+		return err
+	}
+	res, err := send(c.httpClientFor(o), ctx, o, false, func() (*http.Request, error) {
+		return o.newRequest(ctx, http.MethodGet, u, nil)
+	})
+	if err != nil {
+		return err
+	}
+	if !isSuccess(res.StatusCode) {
+		return fmt.Errorf("%w: %w", ErrUnhealthy, newAPIError(res, o.errorCodes))
+	}
+	discardBody(res)
+	return nil
+}
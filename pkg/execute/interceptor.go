@@ -0,0 +1,56 @@
+package execute
+
+import (
+	"net/http"
+)
+
+// RoundTripFunc intercepts a request, next sends it to the following interceptor or the http.Client.
+// An interceptor can modify the request before calling next and inspect or replace the response afterwards.
+type RoundTripFunc func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error)
+
+// WithInterceptors wraps sending requests with the given interceptors.
+// The first interceptor is the outermost one, it sees the request first and the response last.
+// Interceptors are called for every attempt when retries are enabled.
+func WithInterceptors(interceptors ...RoundTripFunc) Option {
+	return func(o *options) {
+		o.interceptors = append(o.interceptors, interceptors...)
+	}
+}
+
+// WithRequestInspector calls inspect with every request right before the http.Client sends it, including retries and streams,
+// e.g. to log the final URL with its wg_variables for debugging. inspect receives a copy of the request after all
+// interceptors ran, changing it has no effect. Its body can be read if the request body is replayable.
+func WithRequestInspector(inspect func(req *http.Request)) Option {
+	return func(o *options) {
+		o.inspect = inspect
+	}
+}
+
+func (o *options) roundTripper(client *http.Client) func(*http.Request) (*http.Response, error) {
+	do := client.Do
+	if o.inspect != nil {
+		do = func(req *http.Request) (*http.Response, error) {
+			o.inspectRequest(req)
+			return client.Do(req)
+		}
+	}
+	for i := len(o.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := o.interceptors[i], do
+		do = func(req *http.Request) (*http.Response, error) {
+			return interceptor(req, next)
+		}
+	}
+	return do
+}
+
+// inspectRequest passes a copy of req to the inspector, so that it can't break the request
+func (o *options) inspectRequest(req *http.Request) {
+	inspected := req.Clone(req.Context())
+	inspected.Body = http.NoBody
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			inspected.Body = body
+		}
+	}
+	o.inspect(inspected)
+}
@@ -0,0 +1,286 @@
+package execute
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// jsonPatchParam asks the server to send live query updates as JSON Patches, see WithJSONPatch
+const jsonPatchParam = "wg_json_patch"
+
+// WithJSONPatch asks the server to send LiveQuery updates as RFC 6902 JSON Patches relative to the previous message.
+// The first message carries the full document, Stream.Next applies the following patches to it
+// and returns the reconstructed document. Messages which aren't patches replace the document if they carry data,
+// messages only carrying errors are returned as they are and the following patches still apply to the last document.
+func WithJSONPatch() Option {
+	return func(o *options) {
+		o.jsonPatch = true
+	}
+}
+
+// applyPatchFrame replaces a patch in s.buf with the patched snapshot, other frames carrying data become the new snapshot.
+// The snapshot is only replaced once the whole patch applied successfully.
+func (s *Stream[Response]) applyPatchFrame() error {
+	frame := bytes.TrimSpace(s.buf.Bytes())
+	if frame[0] != '[' {
+		doc, err := decodeJSONValue(frame)
+		if err != nil {
+			return err
+		}
+		if envelope, ok := doc.(map[string]any); ok && envelope["data"] != nil {
+			s.snapshot = doc
+		}
+		return nil
+	}
+	if s.snapshot == nil {
+		return errors.New("json patch received before the full document")
+	}
+	var (
+		operations []jsonPatchOperation
+	)
+	if err := json.Unmarshal(frame, &operations); err != nil {
+		return fmt.Errorf("invalid json patch: %w", err)
+	}
+	doc, err := copyJSONValue(s.snapshot)
+	if err != nil {
+		return err
+	}
+	if doc, err = applyJSONPatch(doc, operations); err != nil {
+		return err
+	}
+	patched, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	s.snapshot = doc
+	s.buf.Reset()
+	s.buf.Write(patched)
+	return nil
+}
+
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// decodeJSONValue decodes data into maps and slices, numbers are kept as json.Number so that they aren't rounded
+func decodeJSONValue(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var (
+		v any
+	)
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// applyJSONPatch applies operations to doc, which is modified in place
+func applyJSONPatch(doc any, operations []jsonPatchOperation) (any, error) {
+	for _, op := range operations {
+		path, err := parseJSONPointer(op.Path)
+		if err != nil {
+			return nil, err
+		}
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return nil, fmt.Errorf("json patch %s %s: missing value", op.Op, op.Path)
+			}
+			var (
+				value any
+			)
+			if value, err = decodeJSONValue(op.Value); err != nil {
+				return nil, err
+			}
+			switch op.Op {
+			case "add":
+				doc, err = jsonPatchAdd(doc, path, value)
+			case "replace":
+				if doc, _, err = jsonPatchRemove(doc, path); err == nil {
+					doc, err = jsonPatchAdd(doc, path, value)
+				}
+			case "test":
+				var current any
+				if current, err = jsonPatchGet(doc, path); err == nil && !reflect.DeepEqual(current, value) {
+					err = errors.New("test failed")
+				}
+			}
+		case "remove":
+			doc, _, err = jsonPatchRemove(doc, path)
+		case "move", "copy":
+			var (
+				from  []string
+				value any
+			)
+			if from, err = parseJSONPointer(op.From); err != nil {
+				return nil, err
+			}
+			if op.Op == "move" {
+				doc, value, err = jsonPatchRemove(doc, from)
+			} else if value, err = jsonPatchGet(doc, from); err == nil {
+				value, err = copyJSONValue(value)
+			}
+			if err == nil {
+				doc, err = jsonPatchAdd(doc, path, value)
+			}
+		default:
+			err = errors.New("unsupported operation")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("json patch %s %s: %w", op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("invalid json pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// jsonPatchUpdate calls f with the container addressed by all but the last token and the last token,
+// the container returned by f replaces the original one, because arrays change their length
+func jsonPatchUpdate(doc any, path []string, f func(container any, key string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return f(doc, path[0])
+	}
+	switch container := doc.(type) {
+	case map[string]any:
+		child, ok := container[path[0]]
+		if !ok {
+			return nil, errors.New("path not found")
+		}
+		updated, err := jsonPatchUpdate(child, path[1:], f)
+		if err != nil {
+			return nil, err
+		}
+		container[path[0]] = updated
+		return container, nil
+	case []any:
+		i, err := jsonArrayIndex(path[0], len(container)-1)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := jsonPatchUpdate(container[i], path[1:], f)
+		if err != nil {
+			return nil, err
+		}
+		container[i] = updated
+		return container, nil
+	}
+	return nil, errors.New("path not found")
+}
+
+// jsonArrayIndex parses token as index between 0 and max
+func jsonArrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	return i, nil
+}
+
+func jsonPatchGet(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch container := doc.(type) {
+		case map[string]any:
+			child, ok := container[token]
+			if !ok {
+				return nil, errors.New("path not found")
+			}
+			doc = child
+		case []any:
+			i, err := jsonArrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = container[i]
+		default:
+			return nil, errors.New("path not found")
+		}
+	}
+	return doc, nil
+}
+
+func jsonPatchAdd(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return jsonPatchUpdate(doc, path, func(container any, key string) (any, error) {
+		switch container := container.(type) {
+		case map[string]any:
+			container[key] = value
+			return container, nil
+		case []any:
+			if key == "-" {
+				return append(container, value), nil
+			}
+			i, err := jsonArrayIndex(key, len(container))
+			if err != nil {
+				return nil, err
+			}
+			container = append(container, nil)
+			copy(container[i+1:], container[i:])
+			container[i] = value
+			return container, nil
+		}
+		return nil, errors.New("path not found")
+	})
+}
+
+// jsonPatchRemove removes the value at path and returns it
+func jsonPatchRemove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+	var (
+		removed any
+	)
+	doc, err := jsonPatchUpdate(doc, path, func(container any, key string) (any, error) {
+		switch container := container.(type) {
+		case map[string]any:
+			value, ok := container[key]
+			if !ok {
+				return nil, errors.New("path not found")
+			}
+			removed = value
+			delete(container, key)
+			return container, nil
+		case []any:
+			i, err := jsonArrayIndex(key, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			removed = container[i]
+			return append(container[:i], container[i+1:]...), nil
+		}
+		return nil, errors.New("path not found")
+	})
+	return doc, removed, err
+}
+
+func copyJSONValue(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSONValue(data)
+}
@@ -0,0 +1,96 @@
+package execute_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+// liveQueryPatches runs a live query with WithJSONPatch against a server sending frames
+func liveQueryPatches(t *testing.T, frames ...string) *execute.Stream[json.RawMessage] {
+	t.Helper()
+	srv := newStreamServer(t, strings.Join(frames, "\n\n")+"\n\n")
+	stream, err := execute.New(srv.Client(), srv.URL).LiveQuery(context.Background(), "/operations/Items", nil, execute.WithJSONPatch())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = stream.Close()
+	})
+	return stream
+}
+
+func TestLiveQueryJSONPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr string
+	}{
+		{name: "add", doc: `{"data":{"a":1}}`, patch: `[{"op":"add","path":"/data/b","value":{"c":2}}]`, want: `{"a":1,"b":{"c":2}}`},
+		{name: "add to array", doc: `{"data":{"l":[1,3]}}`, patch: `[{"op":"add","path":"/data/l/1","value":2}]`, want: `{"l":[1,2,3]}`},
+		{name: "append to array", doc: `{"data":{"l":[1,2]}}`, patch: `[{"op":"add","path":"/data/l/-","value":3}]`, want: `{"l":[1,2,3]}`},
+		{name: "remove", doc: `{"data":{"a":1,"b":2}}`, patch: `[{"op":"remove","path":"/data/a"}]`, want: `{"b":2}`},
+		{name: "remove from array", doc: `{"data":{"l":[1,2,3]}}`, patch: `[{"op":"remove","path":"/data/l/0"}]`, want: `{"l":[2,3]}`},
+		{name: "replace", doc: `{"data":{"a":1}}`, patch: `[{"op":"replace","path":"/data/a","value":"x"}]`, want: `{"a":"x"}`},
+		{name: "move", doc: `{"data":{"a":1,"b":{}}}`, patch: `[{"op":"move","from":"/data/a","path":"/data/b/c"}]`, want: `{"b":{"c":1}}`},
+		{name: "copy", doc: `{"data":{"a":{"n":1}}}`, patch: `[{"op":"copy","from":"/data/a","path":"/data/b"},{"op":"replace","path":"/data/b/n","value":2}]`, want: `{"a":{"n":1},"b":{"n":2}}`},
+		{name: "test", doc: `{"data":{"a":1}}`, patch: `[{"op":"test","path":"/data/a","value":1},{"op":"replace","path":"/data/a","value":2}]`, want: `{"a":2}`},
+		{name: "large number", doc: `{"data":{"id":9007199254740993}}`, patch: `[{"op":"add","path":"/data/n","value":1}]`, want: `{"id":9007199254740993,"n":1}`},
+		{name: "escaped pointer", doc: `{"data":{"a/b":1,"c~d":2}}`, patch: `[{"op":"replace","path":"/data/a~1b","value":3},{"op":"remove","path":"/data/c~0d"}]`, want: `{"a/b":3}`},
+		{name: "failed test", doc: `{"data":{"a":1}}`, patch: `[{"op":"test","path":"/data/a","value":2}]`, wantErr: "test failed"},
+		{name: "missing path", doc: `{"data":{"a":1}}`, patch: `[{"op":"remove","path":"/data/b"}]`, wantErr: "path not found"},
+		{name: "invalid array index", doc: `{"data":{"l":[1]}}`, patch: `[{"op":"add","path":"/data/l/2","value":2}]`, wantErr: "invalid array index"},
+		{name: "unsupported operation", doc: `{"data":{"a":1}}`, patch: `[{"op":"merge","path":"/data/a","value":2}]`, wantErr: "unsupported operation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := liveQueryPatches(t, tt.doc, tt.patch)
+			ctx := context.Background()
+			if res, closed, err := stream.Next(ctx); err != nil || closed {
+				t.Fatalf("unexpected document %s, closed %v, err %v", deref(res), closed, err)
+			}
+			res, closed, err := stream.Next(ctx)
+			if tt.wantErr != "" {
+				if !closed || err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %s, closed %v, err %v", tt.wantErr, deref(res), closed, err)
+				}
+				return
+			}
+			if err != nil || closed || string(*res) != tt.want {
+				t.Fatalf("expected %s, got %s, closed %v, err %v", tt.want, deref(res), closed, err)
+			}
+		})
+	}
+}
+
+func TestLiveQueryJSONPatchBeforeDocument(t *testing.T) {
+	stream := liveQueryPatches(t, `[{"op":"add","path":"/data/a","value":1}]`)
+	if res, closed, err := stream.Next(context.Background()); !closed || err == nil || !strings.Contains(err.Error(), "before the full document") {
+		t.Fatalf("expected the patch to be rejected, got %s, closed %v, err %v", deref(res), closed, err)
+	}
+}
+
+func TestLiveQueryJSONPatchErrorBetweenPatches(t *testing.T) {
+	stream := liveQueryPatches(t,
+		`{"data":{"n":1}}`,
+		`{"errors":[{"message":"temporarily unavailable"}]}`,
+		`[{"op":"replace","path":"/data/n","value":2}]`,
+	)
+	ctx := context.Background()
+	if res, closed, err := stream.Next(ctx); err != nil || closed || string(*res) != `{"n":1}` {
+		t.Fatalf("unexpected document %s, closed %v, err %v", deref(res), closed, err)
+	}
+	var graphQLErr *execute.GraphQLError
+	if res, closed, err := stream.Next(ctx); closed || !errors.As(err, &graphQLErr) {
+		t.Fatalf("expected a *GraphQLError, got %s, closed %v, err %v", deref(res), closed, err)
+	}
+	if res, closed, err := stream.Next(ctx); err != nil || closed || string(*res) != `{"n":2}` {
+		t.Fatalf("expected the patch to apply to the last document, got %s, closed %v, err %v", deref(res), closed, err)
+	}
+}
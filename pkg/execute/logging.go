@@ -0,0 +1,72 @@
+package execute
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// WithLogger logs every request with its method, URL, status code and duration.
+// Successful requests are logged at debug level, error statuses at warn level and failed requests at error level.
+// For LiveQuery and Subscribe, the end of the stream and reconnect attempts are logged as well.
+// The variables are redacted from the URL unless another function is set with WithURLRedactor.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithURLRedactor sets the function which turns request URLs into their logged representation
+func WithURLRedactor(redact func(u *url.URL) string) Option {
+	return func(o *options) {
+		o.redactURL = redact
+	}
+}
+
+// RedactVariables replaces the value of the wg_variables query parameter, so that secrets in inputs aren't logged verbatim
+func RedactVariables(u *url.URL) string {
+	return redactQueryParam(u, defaultVariablesParam)
+}
+
+func redactQueryParam(u *url.URL, name string) string {
+	query := u.Query()
+	if !query.Has(name) {
+		return u.String()
+	}
+	query.Set(name, "REDACTED")
+	redacted := *u
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+func (o *options) logRequest(ctx context.Context, req *http.Request, res *http.Response, err error, start time.Time) {
+	if o.logger == nil {
+		return
+	}
+	var (
+		redactedURL string
+	)
+	if o.redactURL != nil {
+		redactedURL = o.redactURL(req.URL)
+	} else {
+		redactedURL = redactQueryParam(req.URL, o.variablesParam)
+	}
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", redactedURL),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if requestID := req.Header.Get(requestIDHeader); requestID != "" {
+		attrs = append(attrs, slog.String("request_id", requestID))
+	}
+	switch {
+	case err != nil:
+		o.logger.LogAttrs(ctx, slog.LevelError, "request failed", append(attrs, slog.Any("error", err))...)
+	case res.StatusCode >= http.StatusBadRequest:
+		o.logger.LogAttrs(ctx, slog.LevelWarn, "request returned error status", append(attrs, slog.Int("status", res.StatusCode))...)
+	default:
+		o.logger.LogAttrs(ctx, slog.LevelDebug, "request", append(attrs, slog.Int("status", res.StatusCode))...)
+	}
+}
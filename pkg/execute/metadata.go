@@ -0,0 +1,24 @@
+package execute
+
+import (
+	"time"
+)
+
+// RequestMetadata describes how the response of a call was obtained, see WithRequestMetadata
+type RequestMetadata struct {
+	// Duration is the time the call took, including retries, waiting between them and decoding the response
+	Duration time.Duration
+	// Attempts is the number of requests sent, it's 0 if the response was served from the cache without revalidation
+	// or shared with another call by WithSingleFlight
+	Attempts int
+	// FromCache is set if the response was served from the Cache of WithCache, including revalidated ones
+	FromCache bool
+}
+
+// WithRequestMetadata calls f with the RequestMetadata of every call once it returns, also if it fails,
+// e.g. to track latencies or debug retries. LiveQuery and Subscribe aren't covered.
+func WithRequestMetadata(f func(metadata RequestMetadata)) Option {
+	return func(o *options) {
+		o.onMetadata = f
+	}
+}
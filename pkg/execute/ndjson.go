@@ -0,0 +1,150 @@
+package execute
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// NDJSONReader reads the items of a newline-delimited JSON response one by one, see QueryNDJSON
+type NDJSONReader[Item any] struct {
+	reader         *bufio.Reader
+	body           io.ReadCloser
+	cancel         context.CancelFunc
+	codec          Codec
+	strictDecoding bool
+	// maxLineSize limits the size of a single item, it's set by WithMaxFrameSize
+	maxLineSize int
+	line        bytes.Buffer
+	done        bool
+	// Header carries the headers of the response
+	Header http.Header
+}
+
+// QueryNDJSON executes the query at path, e.g. an export-style operation, whose response is newline-delimited JSON,
+// and returns a NDJSONReader decoding every line into an Item. Empty lines are skipped.
+// WithTimeout limits the whole call including reading the response. The NDJSONReader must be closed.
+func QueryNDJSON[Input any, Item any](client *http.Client, ctx context.Context, baseURL, path string, input *Input, opts ...Option) (*NDJSONReader[Item], error) {
+	return queryNDJSON[Item](New(client, baseURL), ctx, path, input, newOptions(opts))
+}
+
+// QueryNDJSON is like the package level QueryNDJSON, items are returned undecoded
+func (c *Client) QueryNDJSON(ctx context.Context, path string, input any, opts ...Option) (*NDJSONReader[json.RawMessage], error) {
+	return queryNDJSON[json.RawMessage](c, ctx, path, input, c.options(opts))
+}
+
+func queryNDJSON[Item any](c *Client, ctx context.Context, path string, input any, o *options) (*NDJSONReader[Item], error) {
+	_, newQuery, err := newQueryRequest(c, path, input, o)
+	if err != nil {
+		return nil, err
+	}
+	newRequest := func(ctx context.Context) (*http.Request, error) {
+		req, err := newQuery(ctx)
+		if err == nil {
+			o.setAccept(req, "application/x-ndjson")
+		}
+		return req, err
+	}
+	// the response is read after doRequest returns, so the timeout must outlive it
+	var (
+		cancel context.CancelFunc
+	)
+	if o.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		o.timeout = 0
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	r, err := doRequest(c, ctx, o, OperationQuery, path, true, "", o.newRequestID(), newRequest, newNDJSONReader[Item])
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	r.cancel = cancel
+	return r, nil
+}
+
+func newNDJSONReader[Item any](res *http.Response, o *options) (*NDJSONReader[Item], error) {
+	if !isSuccess(res.StatusCode) {
+		return nil, newAPIError(res, o.errorCodes)
+	}
+	r := &NDJSONReader[Item]{
+		reader:         newBufferedReader(responseBody(res, o), o.readBufferSize),
+		body:           res.Body,
+		codec:          o.codec,
+		strictDecoding: o.strictDecoding,
+		maxLineSize:    o.maxFrameSize,
+		Header:         res.Header,
+	}
+	if r.maxLineSize <= 0 {
+		r.maxLineSize = defaultMaxFrameSize
+	}
+	return r, nil
+}
+
+// Next returns the next item, done is set once the response has been read completely.
+// A line which can't be decoded is returned as error without setting done, the following lines can still be read.
+// If the response ends with an incomplete line, Next returns ErrUnexpectedEndOfStream.
+func (r *NDJSONReader[Item]) Next() (item *Item, done bool, err error) {
+	if r.done {
+		return nil, true, nil
+	}
+	for {
+		line, err := r.readLine()
+		line = bytes.TrimSpace(line)
+		if len(line) != 0 && (err == nil || err == io.EOF && json.Valid(line)) {
+			item = new(Item)
+			if err := newDecoder(r.codec, bytes.NewReader(line), r.strictDecoding).Decode(item); err != nil {
+				return nil, false, fmt.Errorf("error decoding response: %w", err)
+			}
+			// io.EOF is returned again by the following call
+			return item, false, nil
+		}
+		if err == nil {
+			// empty line
+			continue
+		}
+		r.done = true
+		_ = r.Close()
+		switch {
+		case err == io.EOF && len(line) != 0:
+			return nil, true, ErrUnexpectedEndOfStream
+		case err == io.EOF:
+			return nil, true, nil
+		case errors.Is(err, ErrFrameTooLarge) || errors.Is(err, ErrResponseTooLarge):
+			return nil, true, err
+		}
+		return nil, true, fmt.Errorf("error reading response: %w", err)
+	}
+}
+
+// readLine reads the next line including the newline, the error is io.EOF if the response ended
+func (r *NDJSONReader[Item]) readLine() ([]byte, error) {
+	r.line.Reset()
+	for {
+		chunk, err := r.reader.ReadSlice('\n')
+		if r.line.Len()+len(chunk) > r.maxLineSize {
+			return nil, ErrFrameTooLarge
+		}
+		r.line.Write(chunk)
+		if err != bufio.ErrBufferFull {
+			return r.line.Bytes(), err
+		}
+	}
+}
+
+// Close closes the response, which stops reading it if not all items were read
+func (r *NDJSONReader[Item]) Close() error {
+	if r == nil || r.body == nil {
+		return nil
+	}
+	defer r.cancel()
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
@@ -0,0 +1,47 @@
+package execute_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+func TestQueryNDJSONAccept(t *testing.T) {
+	var accept []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Values("Accept")
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = w.Write([]byte(`{"id":1}` + "\n" + `{"id":2}` + "\n"))
+	}))
+	defer srv.Close()
+	tests := []struct {
+		name string
+		opts []execute.Option
+		want string
+	}{
+		{name: "default", want: "application/x-ndjson"},
+		{name: "WithHeader", opts: []execute.Option{execute.WithHeader("Accept", "application/jsonl")}, want: "application/jsonl"},
+		{name: "WithDefaultHeaders", opts: []execute.Option{execute.WithDefaultHeaders(http.Header{"Accept": {"application/jsonl"}})}, want: "application/jsonl"},
+		{name: "WithoutDefaultHeaders", opts: []execute.Option{execute.WithoutDefaultHeaders()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := execute.New(srv.Client(), srv.URL, tt.opts...).QueryNDJSON(context.Background(), "/operations/Items", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			if len(accept) > 1 || tt.want == "" && len(accept) != 0 || tt.want != "" && (len(accept) != 1 || accept[0] != tt.want) {
+				t.Fatalf("expected Accept %q, got %v", tt.want, accept)
+			}
+			for _, want := range []string{`{"id":1}`, `{"id":2}`} {
+				if item, done, err := r.Next(); err != nil || done || string(*item) != want {
+					t.Fatalf("expected %s, got %s, done %v, err %v", want, deref(item), done, err)
+				}
+			}
+		})
+	}
+}
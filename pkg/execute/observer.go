@@ -0,0 +1,31 @@
+package execute
+
+import (
+	"time"
+)
+
+// Observer receives a call per request, e.g. to record request counts, latencies and error rates.
+// status is 0 if no response was received. For LiveQuery and Subscribe, every connection attempt is observed.
+// Implementations are called synchronously on the request path and should be cheap.
+type Observer interface {
+	ObserveRequest(op, path string, status int, dur time.Duration)
+}
+
+// StreamObserver can optionally be implemented by an Observer to count the messages received by Stream.Next
+type StreamObserver interface {
+	ObserveStreamMessage(op, path string)
+}
+
+// WithObserver registers an Observer for the call
+func WithObserver(observer Observer) Option {
+	return func(o *options) {
+		o.observer = observer
+	}
+}
+
+func (o *options) observeRequest(op, path string, status int, start time.Time) {
+	if o.observer == nil {
+		return
+	}
+	o.observer.ObserveRequest(op, path, status, time.Since(start))
+}
@@ -0,0 +1,540 @@
+package execute
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultVariablesParam = "wg_variables"
+	defaultLiveParam      = "wg_live"
+	defaultLiveHeader     = "X-WG-Live"
+)
+
+// Option configures a single call to Query, Mutate, LiveQuery or Subscribe, or the defaults of a Client
+type Option func(*options)
+
+type options struct {
+	header     http.Header
+	userAgent  string
+	auth       func(req *http.Request) error
+	retry      *retryOptions
+	idempotent bool
+	timeout    time.Duration
+	postQuery  bool
+	reconnect  *reconnectOptions
+	// urlLengthThreshold is only used by Query
+	urlLengthThreshold int
+	// tokenRefresh is set by WithTokenRefresh
+	tokenRefresh func(ctx context.Context) error
+	// pollingInterval is only used by LiveQuery
+	pollingInterval time.Duration
+	// maxFrameSize and readBufferSize are only used by streams and QueryNDJSON
+	maxFrameSize   int
+	readBufferSize int
+	// maxResponseBytes is only used by Query and Mutate
+	maxResponseBytes int64
+	sse              bool
+	onHeartbeat      func()
+	codec            Codec
+	inputEncoder     func(v any) ([]byte, error)
+	unmarshalers     map[string]func(data []byte, v any) error
+	strictDecoding   bool
+	compression      bool
+	// requestGzip is only used by Mutate
+	requestGzip        bool
+	requestGzipMinSize int
+	tracer             Tracer
+	observer           Observer
+	logger             *slog.Logger
+	redactURL          func(u *url.URL) string
+	interceptors       []RoundTripFunc
+	inspect            func(req *http.Request)
+	variablesParam     string
+	liveParam          string
+	liveHeader         string
+	queryParams        url.Values
+	absoluteURL        string
+	requestFunc        func(ctx context.Context, url string) (*http.Request, error)
+	healthPath         string
+	// operationHash is set by QueryPersisted and MutatePersisted
+	operationHash  string
+	requestID      func() string
+	contextHeaders []func(ctx context.Context) http.Header
+	// defaultHeader is shared by all calls of a Client and must not be modified
+	defaultHeader    http.Header
+	noDefaultHeaders bool
+	// method and idempotencyKey are only used by Mutate and MutateUpload
+	method         string
+	idempotencyKey string
+	// dedupe is only used by streams, dedupeEqual is the func passed to WithDedupe, dedupeFunc the one passed to WithDedupeFunc
+	dedupe       bool
+	dedupeEqual  func(a, b []byte) bool
+	dedupeFunc   any
+	jsonPatch    bool
+	validate     func(input any) error
+	onExtensions func(extensions json.RawMessage)
+	onMetadata   func(metadata RequestMetadata)
+	errorCodes   map[string]error
+	// onStreamEvent is only used by streams
+	onStreamEvent func(event StreamEvent)
+	// circuitThreshold, circuitCooldown, the endpoint options and tlsConfig are only used by New
+	circuitThreshold int
+	circuitCooldown  time.Duration
+	endpoints        []string
+	endpointPolicy   EndpointPolicy
+	endpointCooldown time.Duration
+	tlsConfig        *tls.Config
+	limiter          *rate.Limiter
+	// channelBuffer and channelOverflow are only used by Stream.Channel
+	channelBuffer   int
+	channelOverflow ChannelOverflow
+	transport       http.RoundTripper
+	jar             http.CookieJar
+	checkRedirect   func(req *http.Request, via []*http.Request) error
+	// csrfToken and csrfHeader are only used by Mutate and MutateUpload
+	csrfToken    func(ctx context.Context) (string, error)
+	csrfHeader   string
+	singleFlight bool
+	cache        Cache
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		codec:              DefaultCodec,
+		variablesParam:     defaultVariablesParam,
+		liveParam:          defaultLiveParam,
+		urlLengthThreshold: defaultURLLengthThreshold,
+		healthPath:         defaultHealthPath,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithHeader adds a header to the outgoing request.
+// It can be passed multiple times, values for the same key are accumulated.
+// Setting Content-Type or Accept replaces the defaults.
+func WithHeader(key, value string) Option {
+	return func(o *options) {
+		if o.header == nil {
+			o.header = http.Header{}
+		}
+		o.header.Add(key, value)
+	}
+}
+
+// WithoutDefaultHeaders stops setting Content-Type and Accept to application/json, e.g. for proxies negotiating the content type.
+// Headers set with WithHeader replace the defaults anyway, this option is only needed to omit them.
+func WithoutDefaultHeaders() Option {
+	return func(o *options) {
+		o.noDefaultHeaders = true
+	}
+}
+
+// WithDefaultHeaders adds header to every request, it's meant to be passed to New.
+// Values set with WithHeader on a call replace a default header of the same name.
+// Like WithHeader, a default Accept or Accept-Encoding header replaces the value set by this package.
+// header is copied, so it may be modified afterwards.
+func WithDefaultHeaders(header http.Header) Option {
+	canonical := make(http.Header, len(header))
+	for key, values := range header {
+		canonical[http.CanonicalHeaderKey(key)] = append(canonical[http.CanonicalHeaderKey(key)], values...)
+	}
+	header = canonical
+	return func(o *options) {
+		o.defaultHeader = header
+	}
+}
+
+// WithBearerToken sets the Authorization header to "Bearer <token>"
+func WithBearerToken(token string) Option {
+	return func(o *options) {
+		o.auth = func(req *http.Request) error {
+			req.Header.Set("Authorization", "Bearer "+token)
+			return nil
+		}
+	}
+}
+
+// WithBearerTokenProvider is like WithBearerToken, but calls provider for every request,
+// which allows the token to be refreshed instead of being captured once.
+func WithBearerTokenProvider(provider func() (string, error)) Option {
+	return func(o *options) {
+		o.auth = func(req *http.Request) error {
+			token, err := provider()
+			if err != nil {
+				return fmt.Errorf("getting bearer token: %w", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+			return nil
+		}
+	}
+}
+
+// WithBasicAuth authenticates every request, including streams, with HTTP Basic auth.
+// It replaces WithBearerToken and WithBearerTokenProvider and the other way around, the last one wins.
+func WithBasicAuth(username, password string) Option {
+	return func(o *options) {
+		o.auth = func(req *http.Request) error {
+			req.SetBasicAuth(username, password)
+			return nil
+		}
+	}
+}
+
+// WithTokenRefresh calls refresh once if a request is rejected with 401 Unauthorized and repeats it afterwards,
+// e.g. to renew an expired access token used by WithBearerTokenProvider. If refresh fails or the repeated request
+// is rejected again, the call fails with ErrUnauthorized. refresh is called by all calls which are rejected concurrently.
+// Requests with a body which can't be replayed, e.g. MutateUpload, aren't repeated and fail with ErrUnauthorized after refresh.
+func WithTokenRefresh(refresh func(ctx context.Context) error) Option {
+	return func(o *options) {
+		o.tokenRefresh = refresh
+	}
+}
+
+// WithContextHeaders adds the headers returned by f to every request, e.g. a tenant stored in the context.
+// f is called with the context of the call whenever a request is created, including retries and stream reconnects.
+// Its headers replace headers with the same name set by WithHeader.
+func WithContextHeaders(f func(ctx context.Context) http.Header) Option {
+	return func(o *options) {
+		o.contextHeaders = append(o.contextHeaders, f)
+	}
+}
+
+// WithTimeout limits the duration of a single call without having to set a timeout on the http.Client.
+// For LiveQuery and Subscribe the timeout only applies to establishing the stream, not to its lifetime.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithPostQuery sends a Query as POST request with the variables in the JSON body instead of the URL,
+// which avoids hitting URL length limits (414 Request-URI Too Large) with large inputs.
+// Queries are sent as GET requests by default. LiveQuery and Subscribe send a POST request too,
+// and fail with ErrPostStreamNotSupported if the server rejects it with 405 Method Not Allowed.
+func WithPostQuery() Option {
+	return func(o *options) {
+		o.postQuery = true
+	}
+}
+
+// defaultURLLengthThreshold is the URL length above which queries are sent as POST request
+const defaultURLLengthThreshold = 8000
+
+// WithURLLengthThreshold sends a Query as POST request like WithPostQuery if its URL would be longer than n characters,
+// e.g. because of large variables. It defaults to 8000, which is below the limit of most proxies, 0 disables switching.
+func WithURLLengthThreshold(n int) Option {
+	return func(o *options) {
+		o.urlLengthThreshold = n
+	}
+}
+
+// WithMethod sends Mutate and MutateUpload requests with method instead of POST, e.g. for REST-style routing.
+// Only POST, PUT and PATCH are allowed, other methods fail the call.
+func WithMethod(method string) Option {
+	return func(o *options) {
+		o.method = method
+	}
+}
+
+// mutationMethod returns the HTTP method of mutations
+func (o *options) mutationMethod() (string, error) {
+	switch o.method {
+	case "":
+		return http.MethodPost, nil
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return o.method, nil
+	}
+	return "", fmt.Errorf("unsupported mutation method %q", o.method)
+}
+
+// WithMaxFrameSize limits the size of a single LiveQuery or Subscribe message in bytes, 4MB by default.
+// Stream.Next closes the stream and returns ErrFrameTooLarge once a message exceeds the limit.
+// It limits the lines read by QueryNDJSON too.
+func WithMaxFrameSize(n int) Option {
+	return func(o *options) {
+		o.maxFrameSize = n
+	}
+}
+
+// WithReadBufferSize reads LiveQuery, Subscribe and QueryNDJSON responses with a buffer of n bytes instead of 4096,
+// a larger buffer needs fewer reads for high-throughput streams with large messages
+func WithReadBufferSize(n int) Option {
+	return func(o *options) {
+		o.readBufferSize = n
+	}
+}
+
+// WithMaxResponseBytes limits the size of a Query or Mutate response body in bytes, responses are unlimited by default.
+// Larger responses fail with ErrResponseTooLarge.
+func WithMaxResponseBytes(n int64) Option {
+	return func(o *options) {
+		o.maxResponseBytes = n
+	}
+}
+
+// WithSSE requests LiveQuery and Subscribe streams as Server-Sent Events.
+// Responses with a text/event-stream Content-Type are parsed as Server-Sent Events regardless of this option.
+func WithSSE() Option {
+	return func(o *options) {
+		o.sse = true
+	}
+}
+
+// WithOnHeartbeat registers a callback which is invoked for every keepalive frame of a LiveQuery or Subscribe stream.
+// Keepalive frames are skipped by Stream.Next.
+func WithOnHeartbeat(fn func()) Option {
+	return func(o *options) {
+		o.onHeartbeat = fn
+	}
+}
+
+// WithCodec replaces DefaultCodec for encoding inputs and decoding responses
+func WithCodec(codec Codec) Option {
+	return func(o *options) {
+		o.codec = codec
+	}
+}
+
+// WithInputEncoder encodes inputs with encode instead of the Codec, e.g. to send time.Time fields as epoch milliseconds.
+// It applies to all operations, no matter if the variables are sent in the wg_variables query parameter or the body.
+func WithInputEncoder(encode func(v any) ([]byte, error)) Option {
+	return func(o *options) {
+		o.inputEncoder = encode
+	}
+}
+
+// encodeInput encodes the variables of an operation
+func (o *options) encodeInput(input any) ([]byte, error) {
+	if o.inputEncoder != nil {
+		return o.inputEncoder(input)
+	}
+	return o.codec.Marshal(input)
+}
+
+// WithStrictDecoding fails decoding if the response contains fields which the Response type doesn't model.
+// This requires the Decoder returned by the Codec to implement DisallowUnknownFields, like *json.Decoder does.
+func WithStrictDecoding() Option {
+	return func(o *options) {
+		o.strictDecoding = true
+	}
+}
+
+// WithQueryParam adds key=value to the URL of Query, LiveQuery and Subscribe requests, e.g. for wg_api_hash.
+// It can be used several times, also with the same key to send multiple values.
+func WithQueryParam(key, value string) Option {
+	return func(o *options) {
+		if o.queryParams == nil {
+			o.queryParams = url.Values{}
+		}
+		o.queryParams.Add(key, value)
+	}
+}
+
+// urlParams returns a copy of the parameters added with WithQueryParam
+func (o *options) urlParams() url.Values {
+	params := url.Values{}
+	for key, values := range o.queryParams {
+		params[key] = append([]string(nil), values...)
+	}
+	return params
+}
+
+// WithAbsoluteURL sends the call to u instead of the base URL joined with the path, e.g. to target another gateway.
+// Query parameters like wg_variables are still added. The path keeps identifying the operation,
+// e.g. for tracing and the circuit breaker.
+func WithAbsoluteURL(u string) Option {
+	return func(o *options) {
+		o.absoluteURL = u
+	}
+}
+
+// WithRequestFunc lets newRequest build the requests of the call instead of the package, e.g. to set the method, headers or body itself.
+// url is the URL of the operation including the query parameters like wg_variables, it must be kept, further query parameters
+// can be added. If the request has no body, the body the package would send is set, e.g. the input of a mutation.
+// Headers set by newRequest take precedence over options like WithHeader. The request must carry ctx, as it's called per attempt.
+func WithRequestFunc(newRequest func(ctx context.Context, url string) (*http.Request, error)) Option {
+	return func(o *options) {
+		o.requestFunc = newRequest
+	}
+}
+
+// WithInputValidator validates inputs before they are encoded, e.g. against the JSON Schema of the operation.
+// Calls fail with ErrInvalidInput wrapping the error returned by validate without sending a request.
+func WithInputValidator(validate func(input any) error) Option {
+	return func(o *options) {
+		o.validate = validate
+	}
+}
+
+// validateInput runs the validator set with WithInputValidator
+func (o *options) validateInput(input any) error {
+	if o.validate == nil {
+		return nil
+	}
+	if err := o.validate(input); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	return nil
+}
+
+// WithExtensions calls f with the extensions object of every response and stream message which carries one
+func WithExtensions(f func(extensions json.RawMessage)) Option {
+	return func(o *options) {
+		o.onExtensions = f
+	}
+}
+
+// WithVariablesParam renames the query parameter which carries the variables of Query, LiveQuery and Subscribe,
+// e.g. for gateways in front of WunderGraph using different conventions. It defaults to wg_variables.
+func WithVariablesParam(name string) Option {
+	return func(o *options) {
+		o.variablesParam = name
+	}
+}
+
+// WithLiveParam renames the query parameter which marks a LiveQuery, it defaults to wg_live
+func WithLiveParam(name string) Option {
+	return func(o *options) {
+		o.liveParam = name
+	}
+}
+
+// WithLiveHeader marks a LiveQuery with the header name set to true instead of the wg_live query parameter,
+// e.g. for proxies which strip query parameters. name defaults to X-WG-Live.
+func WithLiveHeader(name string) Option {
+	if name == "" {
+		name = defaultLiveHeader
+	}
+	return func(o *options) {
+		o.liveHeader = name
+	}
+}
+
+func (o *options) newRequest(ctx context.Context, method, url string, body []byte) (*http.Request, error) {
+	var (
+		bodyReader io.Reader
+	)
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	return o.newStreamingRequest(ctx, method, url, bodyReader)
+}
+
+// newStreamingRequest is like newRequest, but takes a body which can't be replayed
+func (o *options) newStreamingRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if o.requestFunc != nil {
+		return o.newCustomRequest(ctx, req)
+	}
+	if err := o.prepareRequest(req); err != nil {
+		return nil, err
+	}
+	if o.tracer != nil {
+		o.tracer.Inject(ctx, req.Header)
+	}
+	return req, nil
+}
+
+// newCustomRequest builds the request with the func set by WithRequestFunc, computed is the request the package would send
+func (o *options) newCustomRequest(ctx context.Context, computed *http.Request) (*http.Request, error) {
+	req, err := o.requestFunc(ctx, computed.URL.String())
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRequestURL(req.URL, computed.URL); err != nil {
+		return nil, err
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		req.Body, req.GetBody, req.ContentLength = computed.Body, computed.GetBody, computed.ContentLength
+	} else if computed.Body != nil {
+		_ = computed.Body.Close()
+	}
+	header := req.Header.Clone()
+	if err := o.prepareRequest(req); err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if o.tracer != nil {
+		o.tracer.Inject(ctx, req.Header)
+	}
+	return req, nil
+}
+
+// checkRequestURL reports an error if u, the URL of a request built by the func set by WithRequestFunc,
+// doesn't target the computed URL or lacks its query parameters
+func checkRequestURL(u, computed *url.URL) error {
+	if u == nil || u.Scheme != computed.Scheme || u.Host != computed.Host || u.Path != computed.Path {
+		return fmt.Errorf("request func: URL %v doesn't match %v", u, computed)
+	}
+	query := u.Query()
+	for key, values := range computed.Query() {
+		if !slices.Equal(query[key], values) {
+			return fmt.Errorf("request func: URL %v lacks query parameter %s", u, key)
+		}
+	}
+	return nil
+}
+
+// hasHeader reports whether key is set with WithHeader or WithDefaultHeaders, which replace the values set by this package
+func (o *options) hasHeader(key string) bool {
+	return o.header.Get(key) != "" || o.defaultHeader.Get(key) != ""
+}
+
+// setAccept replaces the Accept header set by prepareRequest with mediaType, following the same rules
+func (o *options) setAccept(req *http.Request, mediaType string) {
+	if !o.noDefaultHeaders && !o.hasHeader("Accept") {
+		req.Header.Set("Accept", mediaType)
+	}
+}
+
+func (o *options) prepareRequest(req *http.Request) error {
+	if !o.noDefaultHeaders {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+	}
+	if o.userAgent != "" {
+		req.Header.Set("User-Agent", o.userAgent)
+	} else {
+		req.Header.Set("User-Agent", defaultUserAgent())
+	}
+	for key, values := range o.defaultHeader {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	for key, values := range o.header {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	for _, contextHeaders := range o.contextHeaders {
+		for key, values := range contextHeaders(req.Context()) {
+			req.Header[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+		}
+	}
+	if o.compression && !o.hasHeader("Accept-Encoding") {
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+	}
+	if o.auth != nil {
+		if err := o.auth(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package execute_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+func TestDefaultHeaders(t *testing.T) {
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		_, _ = w.Write([]byte(`{"data":{}}`))
+	}))
+	defer srv.Close()
+	tests := []struct {
+		name     string
+		defaults http.Header
+		opts     []execute.Option
+		key      string
+		want     []string
+	}{
+		{name: "default", defaults: http.Header{"x-tenant": {"a"}}, key: "X-Tenant", want: []string{"a"}},
+		{name: "replaced by WithHeader", defaults: http.Header{"X-Tenant": {"a"}}, opts: []execute.Option{execute.WithHeader("x-tenant", "b")}, key: "X-Tenant", want: []string{"b"}},
+		{name: "Accept", defaults: http.Header{"Accept": {"application/graphql-response+json"}}, key: "Accept", want: []string{"application/graphql-response+json"}},
+		{name: "Accept-Encoding", defaults: http.Header{"Accept-Encoding": {"identity"}}, opts: []execute.Option{execute.WithCompression()}, key: "Accept-Encoding", want: []string{"identity"}},
+		{name: "Accept-Encoding of WithCompression", defaults: http.Header{"X-Tenant": {"a"}}, opts: []execute.Option{execute.WithCompression()}, key: "Accept-Encoding", want: []string{"gzip, deflate"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := execute.New(srv.Client(), srv.URL, execute.WithDefaultHeaders(tt.defaults))
+			if err := c.Query(context.Background(), "/operations/Items", nil, nil, tt.opts...); err != nil {
+				t.Fatal(err)
+			}
+			if got := header.Values(tt.key); !slices.Equal(got, tt.want) {
+				t.Fatalf("expected %s: %v, got %v", tt.key, tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package execute
+
+import (
+	"context"
+	"net/http"
+)
+
+// Paginate runs the cursor paginated query at path and calls onPage for every page.
+// nextPage extracts the cursor of the following page from a response and reports whether there is one,
+// setCursor stores the cursor in the input of the next Query. input itself is not modified.
+// Paginate stops at the first error, including errors returned by onPage.
+func Paginate[Input any, Response any](client *http.Client, ctx context.Context, baseURL, path string, input *Input,
+	nextPage func(*Response) (cursor string, more bool), setCursor func(*Input, string), onPage func(*Response) error, opts ...Option) error {
+	var page Input
+	if input != nil {
+		page = *input
+	}
+	c, o := New(client, baseURL), newOptions(opts)
+	for {
+		result, err := query[Response](c, ctx, path, &page, o)
+		if err != nil {
+			return err
+		}
+		if result.Data == nil {
+			return nil
+		}
+		if err := onPage(result.Data); err != nil {
+			return err
+		}
+		cursor, more := nextPage(result.Data)
+		if !more {
+			return nil
+		}
+		setCursor(&page, cursor)
+	}
+}
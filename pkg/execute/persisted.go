@@ -0,0 +1,58 @@
+package execute
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// operationHashParam carries the hash of a persisted operation
+const operationHashParam = "wg_operationHash"
+
+// QueryPersisted executes the persisted query identified by hash, only the hash and the variables are sent.
+// path is the endpoint serving persisted operations, e.g. "/operations".
+func QueryPersisted[Input any, Response any](client *http.Client, ctx context.Context, baseURL, path, hash string, input *Input, opts ...Option) (*Response, error) {
+	o := newOptions(opts)
+	o.operationHash = hash
+	result, err := query[Response](New(client, baseURL), ctx, path, input, o)
+	if result == nil {
+		return nil, err
+	}
+	return result.Data, err
+}
+
+// MutatePersisted executes the persisted mutation identified by hash, only the hash and the variables are sent.
+// path is the endpoint serving persisted operations, e.g. "/operations".
+func MutatePersisted[Input any, Response any](client *http.Client, ctx context.Context, baseURL, path, hash string, input *Input, opts ...Option) (*Response, error) {
+	o := newOptions(opts)
+	o.operationHash = hash
+	result, err := mutate[Response](New(client, baseURL), ctx, path, input, o)
+	if result == nil {
+		return nil, err
+	}
+	return result.Data, err
+}
+
+// QueryPersisted is like the package level QueryPersisted, it decodes the data into response, which must be a pointer
+func (c *Client) QueryPersisted(ctx context.Context, path, hash string, input, response any, opts ...Option) error {
+	o := c.options(opts)
+	o.operationHash = hash
+	result, err := query[json.RawMessage](c, ctx, path, input, o)
+	return decodeInto(result, err, response, o)
+}
+
+// MutatePersisted is like the package level MutatePersisted, it decodes the data into response, which must be a pointer
+func (c *Client) MutatePersisted(ctx context.Context, path, hash string, input, response any, opts ...Option) error {
+	o := c.options(opts)
+	o.operationHash = hash
+	result, err := mutate[json.RawMessage](c, ctx, path, input, o)
+	return decodeInto(result, err, response, o)
+}
+
+// setOperationHash adds the hash of a persisted operation to params
+func (o *options) setOperationHash(params url.Values) {
+	if o.operationHash != "" {
+		params.Set(operationHashParam, o.operationHash)
+	}
+}
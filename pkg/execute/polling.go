@@ -0,0 +1,120 @@
+package execute
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WithPollingFallback makes LiveQuery fall back to executing the query every interval if the server or a proxy
+// rejects the live query with 400 Bad Request or 404 Not Found. The results are delivered by the same Stream,
+// results equal to the previous one are skipped like the server does for live queries.
+// A StreamPollingFallback event is emitted once the fallback engages, see WithStreamEvents.
+// The stream ends with the error of a failed query unless WithAutoReconnect is enabled.
+func WithPollingFallback(interval time.Duration) Option {
+	return func(o *options) {
+		o.pollingInterval = interval
+	}
+}
+
+// canPoll reports whether the live query rejected with res can fall back to polling
+func (o *options) canPoll(res *http.Response) bool {
+	return o.pollingInterval > 0 && (res.StatusCode == http.StatusBadRequest || res.StatusCode == http.StatusNotFound)
+}
+
+// pollError is a failed query of WithPollingFallback, it ends the body of the stream and is returned by Stream.Next
+type pollError struct {
+	err error
+}
+
+func (e *pollError) Error() string {
+	return e.err.Error()
+}
+
+func (e *pollError) Unwrap() error {
+	return e.err
+}
+
+// pollQuery executes the query at path every interval until ctx is done and returns a response
+// carrying the results as stream messages. The first query is executed before pollQuery returns.
+func pollQuery(c *Client, ctx context.Context, path string, input any, o *options) (*http.Response, error) {
+	_, newRequest, err := newQueryRequest(c, path, input, o)
+	if err != nil {
+		return nil, err
+	}
+	client := c.httpClientFor(o)
+	poll := func() ([]byte, http.Header, error) {
+		res, err := send(client, ctx, o, true, func() (*http.Request, error) {
+			return newRequest(ctx)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if !isSuccess(res.StatusCode) {
+			return nil, nil, newAPIError(res, o.errorCodes)
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(responseBody(res, o))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading response: %w", err)
+		}
+		// the message must not contain the blank lines delimiting messages
+		var frame bytes.Buffer
+		if o.sse {
+			frame.WriteString("data: ")
+		}
+		if err := json.Compact(&frame, body); err != nil {
+			return nil, nil, fmt.Errorf("error decoding response: %w", err)
+		}
+		frame.WriteString("\n\n")
+		return frame.Bytes(), res.Header, nil
+	}
+	frame, header, err := poll()
+	if err != nil {
+		return nil, err
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		ticker := time.NewTicker(o.pollingInterval)
+		defer ticker.Stop()
+		previous := frame
+		for {
+			if frame != nil {
+				// fails once the stream is closed
+				if _, err := pw.Write(frame); err != nil {
+					return
+				}
+			}
+			select {
+			case <-ctx.Done():
+				_ = pw.CloseWithError(ctx.Err())
+				return
+			case <-ticker.C:
+			}
+			next, _, err := poll()
+			if err != nil {
+				_ = pw.CloseWithError(&pollError{err: err})
+				return
+			}
+			frame = nil
+			if !bytes.Equal(next, previous) {
+				frame, previous = next, next
+			}
+		}
+	}()
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Body:       pr,
+	}, nil
+}
+
+func isPollError(err error) bool {
+	_, ok := err.(*pollError)
+	return ok
+}
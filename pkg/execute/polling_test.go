@@ -0,0 +1,50 @@
+package execute_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+func TestLiveQueryPollingFallback(t *testing.T) {
+	responses := []string{`{"data":{"n":1}}`, `{"data":{"n":1}}`, `{"data":{"n":2}}`}
+	var polls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("wg_live") == "true" {
+			http.NotFound(w, r)
+			return
+		}
+		n := int(polls.Add(1))
+		if n > len(responses) {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(responses[n-1]))
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := execute.New(srv.Client(), srv.URL).LiveQuery(ctx, "/operations/Counter", nil, execute.WithPollingFallback(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	// the repeated result is skipped
+	for _, want := range []string{`{"n":1}`, `{"n":2}`} {
+		if res, closed, err := stream.Next(ctx); err != nil || closed || string(*res) != want {
+			t.Fatalf("expected %s, got %s, closed %v, err %v", want, deref(res), closed, err)
+		}
+	}
+	res, closed, err := stream.Next(ctx)
+	var apiErr *execute.APIError
+	if !closed || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected the stream to end with the APIError of the failed poll, got %s, closed %v, err %v", deref(res), closed, err)
+	}
+}
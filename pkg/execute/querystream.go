@@ -0,0 +1,144 @@
+package execute
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// QueryDecoder reads the data of a query response incrementally, e.g. to iterate over a large array with Token and More
+// without holding the whole response in memory. The embedded *json.Decoder is positioned at the value of the data field.
+type QueryDecoder struct {
+	*json.Decoder
+	body   io.ReadCloser
+	cancel context.CancelFunc
+	errors []GraphQLErrorEntry
+	// errorCodes is set by WithErrorCodeMapper
+	errorCodes map[string]error
+	// Header carries the headers of the response
+	Header http.Header
+}
+
+// QueryStream executes the query at path and returns a QueryDecoder positioned at the data of the response.
+// The response isn't buffered, so decoding always uses encoding/json instead of the configured Codec.
+// WithTimeout limits the whole call including reading the response. The QueryDecoder must be closed.
+// Errors following the data are returned by QueryDecoder.Err, if the response carries no data field,
+// QueryStream returns them as *GraphQLError.
+func QueryStream[Input any](client *http.Client, ctx context.Context, baseURL, path string, input *Input, opts ...Option) (*QueryDecoder, error) {
+	return queryStream(New(client, baseURL), ctx, path, input, newOptions(opts))
+}
+
+// QueryStream is like the package level QueryStream
+func (c *Client) QueryStream(ctx context.Context, path string, input any, opts ...Option) (*QueryDecoder, error) {
+	return queryStream(c, ctx, path, input, c.options(opts))
+}
+
+func queryStream(c *Client, ctx context.Context, path string, input any, o *options) (*QueryDecoder, error) {
+	_, newRequest, err := newQueryRequest(c, path, input, o)
+	if err != nil {
+		return nil, err
+	}
+	// the response is read after doRequest returns, so the timeout must outlive it
+	var (
+		cancel context.CancelFunc
+	)
+	if o.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		o.timeout = 0
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	d, err := doRequest(c, ctx, o, OperationQuery, path, true, "", o.newRequestID(), newRequest, newQueryDecoder)
+	if d == nil {
+		cancel()
+		return nil, err
+	}
+	d.cancel = cancel
+	return d, err
+}
+
+// newQueryDecoder reads the response up to the value of the data field
+func newQueryDecoder(res *http.Response, o *options) (*QueryDecoder, error) {
+	if !isSuccess(res.StatusCode) {
+		return nil, newAPIError(res, o.errorCodes)
+	}
+	d := &QueryDecoder{
+		Decoder:    json.NewDecoder(responseBody(res, o)),
+		body:       res.Body,
+		errorCodes: o.errorCodes,
+		Header:     res.Header,
+	}
+	if err := d.expectDelim('{'); err != nil {
+		_ = res.Body.Close()
+		return nil, err
+	}
+	found, err := d.readUntilData()
+	if err != nil || !found {
+		_ = res.Body.Close()
+		if err == nil && len(d.errors) != 0 {
+			err = newGraphQLError(d.errors, d.errorCodes)
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+// readUntilData reads the fields of the response, found is false if there's no data field
+func (d *QueryDecoder) readUntilData() (found bool, err error) {
+	for d.More() {
+		token, err := d.Token()
+		if err != nil {
+			return false, fmt.Errorf("error decoding response: %w", err)
+		}
+		switch token {
+		case "data":
+			return true, nil
+		case "errors":
+			if err := d.Decode(&d.errors); err != nil {
+				return false, fmt.Errorf("error decoding response: %w", err)
+			}
+		default:
+			var skip json.RawMessage
+			if err := d.Decode(&skip); err != nil {
+				return false, fmt.Errorf("error decoding response: %w", err)
+			}
+		}
+	}
+	return false, nil
+}
+
+func (d *QueryDecoder) expectDelim(delim json.Delim) error {
+	token, err := d.Token()
+	if err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	if token != delim {
+		return fmt.Errorf("error decoding response: expected %v, got %v", delim, token)
+	}
+	return nil
+}
+
+// Err reads the fields following the data, which must have been read completely,
+// and returns a *GraphQLError if the response carries errors
+func (d *QueryDecoder) Err() error {
+	if _, err := d.readUntilData(); err != nil {
+		return err
+	}
+	if len(d.errors) != 0 {
+		return newGraphQLError(d.errors, d.errorCodes)
+	}
+	return nil
+}
+
+// Close closes the response, which stops reading it if the data wasn't read completely
+func (d *QueryDecoder) Close() error {
+	if d == nil || d.body == nil {
+		return nil
+	}
+	defer d.cancel()
+	err := d.body.Close()
+	d.body = nil
+	return err
+}
@@ -0,0 +1,14 @@
+package execute
+
+import (
+	"golang.org/x/time/rate"
+)
+
+// WithRateLimit makes calls wait for a token of limiter before sending a request, bounded by their context.
+// Every attempt of a retried call consumes a token. Streams only consume tokens to connect and reconnect,
+// messages are never limited. Share the limiter between calls, e.g. by passing this option to New.
+func WithRateLimit(limiter *rate.Limiter) Option {
+	return func(o *options) {
+		o.limiter = limiter
+	}
+}
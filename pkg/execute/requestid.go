@@ -0,0 +1,38 @@
+package execute
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// requestIDHeader carries the ID generated by WithRequestID
+const requestIDHeader = "X-Request-Id"
+
+// WithRequestID sets an X-Request-Id header generated by generate on every request, if generate is nil random UUIDs are used.
+// The ID is created once per call, so retries share it, and it's reported by Result.RequestID and Stream.RequestID.
+// Streams keep their ID when they reconnect.
+func WithRequestID(generate func() string) Option {
+	if generate == nil {
+		generate = newUUID
+	}
+	return func(o *options) {
+		o.requestID = generate
+	}
+}
+
+// newRequestID returns the ID of a new call, it's empty unless WithRequestID is used
+func (o *options) newRequestID() string {
+	if o.requestID == nil {
+		return ""
+	}
+	return o.requestID()
+}
+
+// newUUID returns a random version 4 UUID
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,276 @@
+package execute
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// BackoffFunc returns how long to wait before the given retry, attempt starts at 1
+type BackoffFunc func(attempt int) time.Duration
+
+type retryOptions struct {
+	maxAttempts    int
+	backoff        BackoffFunc
+	statusCodes    []int
+	attemptTimeout time.Duration
+}
+
+var defaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// WithRetry retries failed requests up to maxAttempts attempts in total.
+// Network errors and the status codes configured with WithRetryableStatusCodes
+// (429, 502, 503 and 504 by default) are retried, a Retry-After header sent by the server
+// takes precedence over backoff. If backoff is nil, ExponentialBackoffWithJitter with a base of 100ms and a maximum of 10s
+// is used, see also ConstantBackoff and ExponentialBackoff.
+// Mutations are only retried when WithIdempotent or WithIdempotencyKey is passed as well.
+func WithRetry(maxAttempts int, backoff BackoffFunc) Option {
+	return func(o *options) {
+		if o.retry == nil {
+			o.retry = &retryOptions{
+				statusCodes: defaultRetryableStatusCodes,
+			}
+		}
+		o.retry.maxAttempts = maxAttempts
+		o.retry.backoff = backoff
+	}
+}
+
+// WithRetryableStatusCodes replaces the status codes that are retried when WithRetry is enabled
+func WithRetryableStatusCodes(statusCodes ...int) Option {
+	return func(o *options) {
+		if o.retry == nil {
+			o.retry = &retryOptions{}
+		}
+		o.retry.statusCodes = statusCodes
+	}
+}
+
+// WithPerAttemptTimeout limits every attempt of a call retried with WithRetry to d, including reading the response,
+// so that a single slow attempt doesn't use up the whole time available. Attempts which time out are retried.
+// The context of the call and WithTimeout still bound all attempts together. LiveQuery and Subscribe aren't affected.
+func WithPerAttemptTimeout(d time.Duration) Option {
+	return func(o *options) {
+		if o.retry == nil {
+			o.retry = &retryOptions{
+				statusCodes: defaultRetryableStatusCodes,
+			}
+		}
+		o.retry.attemptTimeout = d
+	}
+}
+
+type reconnectOptions struct {
+	maxRetries int
+	backoff    BackoffFunc
+}
+
+// WithAutoReconnect makes LiveQuery and Subscribe streams re-establish a dropped connection,
+// trying up to maxRetries times before Stream.Next gives up. If backoff is nil, the default of WithRetry is used.
+func WithAutoReconnect(maxRetries int, backoff BackoffFunc) Option {
+	return func(o *options) {
+		o.reconnect = &reconnectOptions{
+			maxRetries: maxRetries,
+			backoff:    backoff,
+		}
+	}
+}
+
+// WithIdempotent marks a mutation as safe to retry
+func WithIdempotent() Option {
+	return func(o *options) {
+		o.idempotent = true
+	}
+}
+
+// idempotencyKeyHeader lets the server recognize retries of a mutation, see WithIdempotencyKey
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// WithIdempotencyKey sends key in the Idempotency-Key header of a mutation, which the server uses to detect duplicates,
+// and marks the mutation as safe to retry like WithIdempotent. Every attempt of the call sends the same key.
+// When WithRetry and WithIdempotent are used without a key, a random one is generated per call.
+func WithIdempotencyKey(key string) Option {
+	return func(o *options) {
+		o.idempotencyKey = key
+		o.idempotent = true
+	}
+}
+
+// newIdempotencyKey returns the Idempotency-Key of a mutation, it's empty if the mutation isn't retried
+func (o *options) newIdempotencyKey() string {
+	if o.idempotencyKey != "" {
+		return o.idempotencyKey
+	}
+	if o.idempotent && o.retry.attempts() > 1 {
+		return newUUID()
+	}
+	return ""
+}
+
+func (r *retryOptions) attempts() int {
+	if r == nil || r.maxAttempts < 1 {
+		return 1
+	}
+	return r.maxAttempts
+}
+
+func (r *retryOptions) shouldRetry(ctx context.Context, res *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	for _, statusCode := range r.statusCodes {
+		if res.StatusCode == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *retryOptions) wait(attempt int, res *http.Response) time.Duration {
+	if res != nil {
+		if d, ok := retryAfter(res.Header); ok {
+			return d
+		}
+	}
+	if r.backoff == nil {
+		return defaultBackoff(attempt)
+	}
+	return r.backoff(attempt)
+}
+
+// retryAfter parses the Retry-After header, which is either a number of seconds or an HTTP date
+func retryAfter(header http.Header) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		d := time.Until(date)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// send executes the request returned by newRequest, retrying it according to the retry options if retry is true.
+// newRequest is invoked once per attempt so that the request body can be replayed.
+func send(client *http.Client, ctx context.Context, o *options, retry bool, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	attempts := 1
+	if retry {
+		attempts = o.retry.attempts()
+	}
+	do := o.roundTripper(client)
+	refreshed := false
+	for attempt := 1; ; attempt++ {
+		if o.limiter != nil {
+			if err := o.limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		var (
+			cancelAttempt context.CancelFunc
+		)
+		if retry && o.retry != nil && o.retry.attemptTimeout > 0 {
+			var attemptCtx context.Context
+			attemptCtx, cancelAttempt = context.WithTimeout(req.Context(), o.retry.attemptTimeout)
+			req = req.WithContext(attemptCtx)
+		}
+		start := time.Now()
+		res, err := do(req)
+		o.logRequest(ctx, req, res, err, start)
+		if err == nil && res.StatusCode == http.StatusUnauthorized && o.tokenRefresh != nil && !refreshed {
+			refreshed = true
+			if err := o.tokenRefresh(ctx); err != nil {
+				discardBody(res)
+				if cancelAttempt != nil {
+					cancelAttempt()
+				}
+				return nil, fmt.Errorf("%w: refreshing token: %w", ErrUnauthorized, err)
+			}
+			if replayable(req) {
+				// the request is repeated with the refreshed credentials, which doesn't count as retry
+				discardBody(res)
+				if cancelAttempt != nil {
+					cancelAttempt()
+				}
+				attempt--
+				continue
+			}
+			// the body, e.g. the files of MutateUpload, was consumed and can't be sent again,
+			// the refreshed credentials are only used by later calls and the 401 is returned
+		}
+		if attempt >= attempts || !o.retry.shouldRetry(ctx, res, err) {
+			if err != nil {
+				if cancelAttempt != nil {
+					cancelAttempt()
+				}
+				return nil, requestError(req, err)
+			}
+			if o.compression {
+				decompressResponse(res)
+			}
+			if cancelAttempt != nil {
+				// the timeout of the attempt covers reading the body
+				res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancelAttempt}
+			}
+			return res, nil
+		}
+		wait := o.retry.wait(attempt, res)
+		if res != nil {
+			discardBody(res)
+		}
+		if cancelAttempt != nil {
+			cancelAttempt()
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// replayable reports whether the body of req can be sent again
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// cancelOnClose cancels the context of an attempt once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// discardBody drains and closes the body of a response which isn't used, so that the connection can be reused
+func discardBody(res *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxErrorBodySize))
+	_ = res.Body.Close()
+}
@@ -0,0 +1,55 @@
+package execute
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ServerTrace is the Apollo tracing format the server adds as extensions.tracing, see DecodeServerTrace.
+// Offsets are relative to StartTime.
+type ServerTrace struct {
+	Version    int                  `json:"version"`
+	StartTime  time.Time            `json:"startTime"`
+	EndTime    time.Time            `json:"endTime"`
+	Duration   time.Duration        `json:"duration"`
+	Parsing    ServerTracePhase     `json:"parsing"`
+	Validation ServerTracePhase     `json:"validation"`
+	Execution  ServerTraceExecution `json:"execution"`
+}
+
+// ServerTracePhase is the timing of parsing or validating the operation
+type ServerTracePhase struct {
+	StartOffset time.Duration `json:"startOffset"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// ServerTraceExecution holds the timings of all resolvers
+type ServerTraceExecution struct {
+	Resolvers []ResolverTrace `json:"resolvers"`
+}
+
+// ResolverTrace is the timing of a single resolver, Path contains field names and list indexes, e.g. ["users", 0, "name"]
+type ResolverTrace struct {
+	Path        []any         `json:"path"`
+	ParentType  string        `json:"parentType"`
+	FieldName   string        `json:"fieldName"`
+	ReturnType  string        `json:"returnType"`
+	StartOffset time.Duration `json:"startOffset"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// DecodeServerTrace decodes the tracing field of extensions, e.g. Result.Extensions or the extensions passed to the func of WithExtensions.
+// It returns nil if the server didn't add tracing information. Decoding only happens when it's called, so it doesn't cost anything otherwise.
+func DecodeServerTrace(extensions json.RawMessage) (*ServerTrace, error) {
+	if !hasJSONValue(extensions) {
+		return nil, nil
+	}
+	var envelope struct {
+		Tracing *ServerTrace `json:"tracing"`
+	}
+	if err := json.Unmarshal(extensions, &envelope); err != nil {
+		return nil, fmt.Errorf("error decoding server trace: %w", err)
+	}
+	return envelope.Tracing, nil
+}
@@ -0,0 +1,96 @@
+package execute
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// Session starts LiveQuery and Subscribe streams on a connection pool of their own, separate from other calls of the Client,
+// so that a UI with many live queries doesn't open a connection with its own TLS handshake per stream.
+// With an https base URL and a server supporting HTTP/2, all streams are multiplexed over a single connection.
+// Over http://, or if the server only supports HTTP/1.1, every stream opens its own connection,
+// unencrypted HTTP/2 (h2c) isn't supported.
+// The streams are independent, every stream can be closed on its own, the connections are closed once the last stream is closed.
+// Calls passing WithTransport don't use the connections of the Session.
+type Session struct {
+	client *Client
+	// transport is nil if the http.Client has a Transport other than *http.Transport, which is used as it is then
+	transport *http.Transport
+	// dial is held while the first stream is started, see open
+	dial    sync.Mutex
+	mu      sync.Mutex
+	streams int
+}
+
+// NewSession creates a Session using the options of c. If the http.Client of c has an *http.Transport,
+// the Session uses a copy of it, so that its connections aren't shared with other calls of c.
+func (c *Client) NewSession() *Session {
+	s := &Session{}
+	switch transport := c.httpClient.Transport.(type) {
+	case nil:
+		s.transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		s.transport = transport.Clone()
+	}
+	httpClient := *c.httpClient
+	if s.transport != nil {
+		s.transport.ForceAttemptHTTP2 = true
+		httpClient.Transport = s.transport
+	}
+	client := *c
+	client.httpClient = &httpClient
+	s.client = &client
+	return s
+}
+
+// LiveQuery is like Client.LiveQuery, the stream uses the connection of the Session
+func (s *Session) LiveQuery(ctx context.Context, path string, input any, opts ...Option) (*Stream[json.RawMessage], error) {
+	return s.open(func() (*Stream[json.RawMessage], error) {
+		return buildStream[json.RawMessage](s.client, ctx, path, true, input, s.client.options(opts))
+	})
+}
+
+// Subscribe is like Client.Subscribe, the stream uses the connection of the Session
+func (s *Session) Subscribe(ctx context.Context, path string, input any, opts ...Option) (*Stream[json.RawMessage], error) {
+	return s.open(func() (*Stream[json.RawMessage], error) {
+		return buildStream[json.RawMessage](s.client, ctx, path, false, input, s.client.options(opts))
+	})
+}
+
+// open starts a stream with newStream. While the Session has no open stream, streams are started one at a time,
+// otherwise concurrent streams would each dial a connection before the first one negotiated HTTP/2.
+func (s *Session) open(newStream func() (*Stream[json.RawMessage], error)) (*Stream[json.RawMessage], error) {
+	s.dial.Lock()
+	s.mu.Lock()
+	connected := s.streams > 0
+	s.mu.Unlock()
+	if connected {
+		s.dial.Unlock()
+	} else {
+		defer s.dial.Unlock()
+	}
+	return s.start(newStream())
+}
+
+func (s *Session) start(stream *Stream[json.RawMessage], err error) (*Stream[json.RawMessage], error) {
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.streams++
+	s.mu.Unlock()
+	stream.onClose = s.release
+	return stream, nil
+}
+
+// release closes the connections of the Session once the last stream is closed
+func (s *Session) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streams--
+	if s.streams == 0 && s.transport != nil {
+		s.transport.CloseIdleConnections()
+	}
+}
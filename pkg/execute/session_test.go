@@ -0,0 +1,81 @@
+package execute_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+func TestSessionSharesOneConnection(t *testing.T) {
+	const streams = 20
+	var opened, closed atomic.Int64
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"data":{"n":1}}`+"\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	srv.EnableHTTP2 = true
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			opened.Add(1)
+		case http.StateClosed:
+			closed.Add(1)
+		}
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	session := execute.New(srv.Client(), srv.URL).NewSession()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		started []*execute.Stream[json.RawMessage]
+	)
+	for i := 0; i < streams; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stream, err := session.Subscribe(ctx, "/operations/Counter", nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if res, closed, err := stream.Next(ctx); err != nil || closed || string(*res) != `{"n":1}` {
+				t.Errorf("unexpected message %s, closed %v, err %v", deref(res), closed, err)
+			}
+			mu.Lock()
+			started = append(started, stream)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if n := opened.Load(); n != 1 {
+		t.Fatalf("expected the streams to share 1 connection, got %d", n)
+	}
+	for _, stream := range started {
+		if closed.Load() != 0 {
+			t.Fatal("the connection was closed before the last stream")
+		}
+		_ = stream.Close()
+	}
+	for closed.Load() != 1 {
+		select {
+		case <-ctx.Done():
+			t.Fatal("the connection wasn't closed after the last stream")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
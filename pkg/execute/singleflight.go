@@ -0,0 +1,71 @@
+package execute
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// WithSingleFlight coalesces concurrent identical queries of a Client into a single request.
+// Queries are identical if they use the same path and variables, every caller decodes its own copy of the response.
+// The request is sent with the options of the first caller, so all callers should use the same credentials.
+// Mutations and queries sent as POST are never coalesced. This option only has an effect when passed to New.
+func WithSingleFlight() Option {
+	return func(o *options) {
+		o.singleFlight = true
+	}
+}
+
+type sharedResponse struct {
+	res  *http.Response
+	body []byte
+}
+
+// sendShared is like send, but concurrent calls with the same key share the response.
+// The shared request runs on a context detached from the callers, so that one caller giving up doesn't fail the others,
+// it's bounded by WithTimeout of the first caller. Every caller stops waiting once its own ctx is done.
+func (c *Client) sendShared(ctx context.Context, o *options, key string, retry bool, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	ch := c.flight.DoChan(key, func() (any, error) {
+		flightCtx := context.WithoutCancel(ctx)
+		if o.timeout > 0 {
+			var cancel context.CancelFunc
+			flightCtx, cancel = context.WithTimeout(flightCtx, o.timeout)
+			defer cancel()
+		}
+		res, err := send(c.httpClientFor(o), flightCtx, o, retry, func() (*http.Request, error) {
+			req, err := newRequest()
+			if err != nil {
+				return nil, err
+			}
+			return req.WithContext(flightCtx), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(responseBody(res, o))
+		if err != nil {
+			return nil, err
+		}
+		return &sharedResponse{res: res, body: body}, nil
+	})
+	var (
+		result singleflight.Result
+	)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result = <-ch:
+	}
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	shared := result.Val.(*sharedResponse)
+	res := *shared.res
+	res.Header = shared.res.Header.Clone()
+	res.Body = io.NopCloser(bytes.NewReader(shared.body))
+	return &res, nil
+}
@@ -0,0 +1,85 @@
+package execute_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+// waitingContext reports the first call of Done, sendShared calls it once the caller waits for the shared request
+type waitingContext struct {
+	context.Context
+	once    sync.Once
+	waiting chan struct{}
+}
+
+func (c *waitingContext) Done() <-chan struct{} {
+	c.once.Do(func() {
+		close(c.waiting)
+	})
+	return c.Context.Done()
+}
+
+func TestSingleFlightCallerGivingUpDoesNotFailOthers(t *testing.T) {
+	var requests atomic.Int32
+	received, release := make(chan struct{}), make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			close(received)
+		}
+		<-release
+		_, _ = w.Write([]byte(`{"data":{"id":1}}`))
+	}))
+	defer srv.Close()
+	c := execute.New(srv.Client(), srv.URL, execute.WithSingleFlight())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	first := make(chan error, 1)
+	go func() {
+		var response map[string]any
+		first <- c.Query(ctx, "/operations/Users", nil, &response)
+	}()
+	<-received
+	joined := &waitingContext{Context: context.Background(), waiting: make(chan struct{})}
+	second := make(chan error, 1)
+	var response map[string]any
+	go func() {
+		second <- c.Query(joined, "/operations/Users", nil, &response)
+	}()
+	<-joined.waiting
+	// the first caller started the shared request and gives up
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the first caller to be canceled, got %v", err)
+	}
+	close(release)
+	if err := <-second; err != nil {
+		t.Fatalf("expected the second caller to succeed, got %v", err)
+	}
+	if response["id"] != float64(1) {
+		t.Fatalf("unexpected response %v", response)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("expected 1 request, got %d", n)
+	}
+}
+
+func TestSingleFlightAppliesMaxResponseBytes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"name":"` + strings.Repeat("a", 1024) + `"}}`))
+	}))
+	defer srv.Close()
+	c := execute.New(srv.Client(), srv.URL, execute.WithSingleFlight(), execute.WithMaxResponseBytes(100))
+	var response map[string]any
+	if err := c.Query(context.Background(), "/operations/Users", nil, &response); !errors.Is(err, execute.ErrResponseTooLarge) {
+		t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,417 @@
+package execute
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// defaultMaxFrameSize is the maximum size of a single stream message unless configured with WithMaxFrameSize
+const defaultMaxFrameSize = 4 << 20
+
+type Stream[Response any] struct {
+	header http.Header
+	body   io.ReadCloser
+	cancel context.CancelFunc
+	reader *bufio.Reader
+	buf    *bytes.Buffer
+	closed bool
+	// maxFrameSize limits the size of a single message
+	maxFrameSize int
+	// readBufferSize is set by WithReadBufferSize
+	readBufferSize int
+	// sse switches the framing to Server-Sent Events, forceSSE is set by WithSSE
+	sse      bool
+	forceSSE bool
+	line     []byte
+	// onHeartbeat is called for every keepalive frame
+	onHeartbeat    func()
+	codec          Codec
+	strictDecoding bool
+	// ctx is the context passed to LiveQuery or Subscribe, it bounds reconnects
+	ctx       context.Context
+	open      func(ctx context.Context) (*http.Response, context.CancelFunc, error)
+	reconnect *reconnectOptions
+	span      Span
+	// observer counts messages, operation and path are passed to it
+	observer        StreamObserver
+	operation, path string
+	logger          *slog.Logger
+	requestID       string
+	// draining is set by CloseGraceful, it bounds how long remaining messages can be read
+	draining context.Context
+	drained  bool
+	// dedupe reports whether a frame repeats the previous one, it's set by WithDedupe
+	dedupe func(frame []byte) bool
+	// jsonPatch is set by WithJSONPatch, snapshot is the document patches are applied to
+	jsonPatch bool
+	snapshot  any
+	// onExtensions is set by WithExtensions
+	onExtensions func(extensions json.RawMessage)
+	// errorCodes is set by WithErrorCodeMapper
+	errorCodes map[string]error
+	// channelBuffer and channelOverflow are set by WithChannelBuffer
+	channelBuffer   int
+	channelOverflow ChannelOverflow
+	// err is the error the stream ended with, see Err
+	err error
+	// onStreamEvent is set by WithStreamEvents, messages counts the received messages
+	onStreamEvent func(event StreamEvent)
+	messages      int
+	// onClose is set by Session, it's called once the stream is closed
+	onClose func()
+}
+
+func newStream[Response any](ctx context.Context, res *http.Response, cancel context.CancelFunc, o *options) *Stream[Response] {
+	s := &Stream[Response]{
+		ctx:             ctx,
+		buf:             &bytes.Buffer{},
+		maxFrameSize:    o.maxFrameSize,
+		readBufferSize:  o.readBufferSize,
+		forceSSE:        o.sse,
+		onHeartbeat:     o.onHeartbeat,
+		codec:           o.codec,
+		strictDecoding:  o.strictDecoding,
+		logger:          o.logger,
+		jsonPatch:       o.jsonPatch,
+		onExtensions:    o.onExtensions,
+		errorCodes:      o.errorCodes,
+		channelBuffer:   o.channelBuffer,
+		channelOverflow: o.channelOverflow,
+		onStreamEvent:   o.onStreamEvent,
+	}
+	if s.maxFrameSize <= 0 {
+		s.maxFrameSize = defaultMaxFrameSize
+	}
+	if o.dedupe {
+		s.dedupe = newDedupe(o.dedupeEqual, o.codec)
+	}
+	s.setResponse(res, cancel)
+	return s
+}
+
+func (s *Stream[Response]) setResponse(res *http.Response, cancel context.CancelFunc) {
+	s.header = res.Header
+	s.body = res.Body
+	s.cancel = cancel
+	s.reader = newBufferedReader(res.Body, s.readBufferSize)
+	s.sse = s.forceSSE || isEventStream(res.Header)
+	// the server starts with the full document after reconnecting
+	s.snapshot = nil
+}
+
+// newBufferedReader returns a bufio.Reader of size bytes, or the default size if size isn't positive
+func newBufferedReader(r io.Reader, size int) *bufio.Reader {
+	if size <= 0 {
+		return bufio.NewReader(r)
+	}
+	return bufio.NewReaderSize(r, size)
+}
+
+// Header returns the headers of the response that established the stream
+func (s *Stream[Response]) Header() http.Header {
+	if s == nil {
+		return nil
+	}
+	return s.header
+}
+
+// Err returns the error the stream ended with, like the last Next did, it's nil if the stream ended cleanly or is still open.
+// It's useful after the channels returned by Channel are closed.
+func (s *Stream[Response]) Err() error {
+	if s == nil {
+		return nil
+	}
+	return s.err
+}
+
+// RequestID returns the ID sent with WithRequestID, it's kept across reconnects
+func (s *Stream[Response]) RequestID() string {
+	if s == nil {
+		return ""
+	}
+	return s.requestID
+}
+
+func (s *Stream[Response]) Close() error {
+	if s == nil || s.body == nil {
+		return nil
+	}
+	return s.closeWithError(nil)
+}
+
+// closeWithError closes the stream and ends its span, err is the reason the stream ended
+func (s *Stream[Response]) closeWithError(err error) error {
+	if s.closed {
+		return nil
+	}
+	s.closed, s.err = true, err
+	if s.logger != nil {
+		if err != nil {
+			s.logger.LogAttrs(s.ctx, slog.LevelWarn, "stream closed", slog.Any("error", err))
+		} else {
+			s.logger.LogAttrs(s.ctx, slog.LevelDebug, "stream closed")
+		}
+	}
+	if s.span != nil {
+		s.span.End(err)
+		s.span = nil
+	}
+	s.emit(StreamClosed{Err: err})
+	closeErr := s.closeBody()
+	if s.onClose != nil {
+		s.onClose()
+	}
+	return closeErr
+}
+
+// CloseGraceful ends the stream once ctx is done, messages received until then, including those already buffered
+// when ctx is done, can still be read with Next.
+// Next then ends the stream without error, also if the server ends it first, and the stream isn't reconnected anymore.
+func (s *Stream[Response]) CloseGraceful(ctx context.Context) {
+	if s == nil || s.body == nil || s.closed || s.draining != nil {
+		return
+	}
+	s.draining = ctx
+	// canceling the request unblocks a pending read, which ends the stream
+	context.AfterFunc(ctx, s.cancel)
+}
+
+func (s *Stream[Response]) closeBody() error {
+	if s.cancel != nil {
+		defer s.cancel()
+	}
+	return s.body.Close()
+}
+
+// Next blocks until the next message arrives.
+// closed reports whether the stream has ended, messages containing errors are returned as *GraphQLError with closed set to false.
+// If the server completes the stream, closed is set without error, ErrUnexpectedEndOfStream means the connection ended mid-message.
+// If WithAutoReconnect is enabled and the connection drops, Next re-establishes the stream and returns ErrReconnected,
+// messages might have been missed in between.
+// Canceling ctx closes the stream, even while Next waits for a server which stopped sending mid-message.
+// Closed is then set without error if ctx was canceled, but with an error wrapping context.DeadlineExceeded if its deadline passed.
+func (s *Stream[Response]) Next(ctx context.Context) (res *Response, closed bool, err error) {
+	closed, err = s.next(ctx, func(frame []byte) ([]GraphQLErrorEntry, json.RawMessage, error) {
+		var envelope responseEnvelope[Response]
+		if err := decodeFrame(s.codec, s.strictDecoding, frame, &envelope); err != nil {
+			return nil, nil, err
+		}
+		res = envelope.Data
+		return envelope.Errors, envelope.Extensions, nil
+	})
+	return res, closed, err
+}
+
+// NextInto is like Next, but decodes the message into out instead of allocating a new Response.
+// out is reset and overwritten by every call, so it can be reused across iterations.
+// If a message carries no data, out is left zeroed.
+func (s *Stream[Response]) NextInto(ctx context.Context, out *Response) (closed bool, err error) {
+	if out == nil {
+		return false, errors.New("NextInto: out must not be nil")
+	}
+	return s.next(ctx, func(frame []byte) ([]GraphQLErrorEntry, json.RawMessage, error) {
+		var zero Response
+		*out = zero
+		envelope := responseEnvelope[Response]{Data: out}
+		err := decodeFrame(s.codec, s.strictDecoding, frame, &envelope)
+		return envelope.Errors, envelope.Extensions, err
+	})
+}
+
+// NextRaw is like Next, but returns the data of the message undecoded, e.g. to pass it on verbatim.
+// The returned bytes are a copy and stay valid after the next call.
+func (s *Stream[Response]) NextRaw(ctx context.Context) (res json.RawMessage, closed bool, err error) {
+	closed, err = s.next(ctx, func(frame []byte) ([]GraphQLErrorEntry, json.RawMessage, error) {
+		var envelope responseEnvelope[json.RawMessage]
+		if err := decodeFrame(s.codec, s.strictDecoding, frame, &envelope); err != nil {
+			return nil, nil, err
+		}
+		if envelope.Data != nil {
+			res = *envelope.Data
+		}
+		return envelope.Errors, envelope.Extensions, nil
+	})
+	return res, closed, err
+}
+
+// next reads the next message and passes it to decode
+func (s *Stream[Response]) next(ctx context.Context, decode func(frame []byte) ([]GraphQLErrorEntry, json.RawMessage, error)) (closed bool, err error) {
+	defer func() {
+		// if we cancel the context, the server can close the stream while sending the next response
+		// this might lead to unexpected errors which we'd like to catch, because it would be unexpected
+		// this defer func simply cleans up the return values in case of a context cancelation,
+		// a passed deadline is reported though, so that callers can tell a timeout from stopping the stream
+		if ctx.Err() != nil {
+			err = contextError(ctx)
+			closed = true
+			if s != nil {
+				s.err = err
+			}
+		}
+	}()
+	if s == nil || s.closed || s.buf == nil || s.reader == nil {
+		_ = s.Close()
+		return true, ErrStreamClosed
+	}
+	if s.draining != nil && s.draining.Err() != nil && !s.drained {
+		// stop reading from the connection, only the messages buffered already are returned,
+		// the end of the buffer ends the stream
+		buffered, _ := s.reader.Peek(s.reader.Buffered())
+		s.reader = bufio.NewReader(bytes.NewReader(bytes.Clone(buffered)))
+		s.drained = true
+	}
+	if s.cancel != nil {
+		// a hung server might never send the next byte, canceling the request unblocks the pending read
+		stop := context.AfterFunc(ctx, s.cancel)
+		defer stop()
+	}
+	readFrame := s.readFrame
+	if s.sse {
+		readFrame = s.readSSEFrame
+	}
+	for {
+		for {
+			if err := readFrame(ctx); err != nil {
+				switch {
+				case ctx.Err() != nil:
+					// context canceled, stop reading
+					err := contextError(ctx)
+					_ = s.closeWithError(err)
+					return true, err
+				case err == errStreamEnded:
+					// the server completed the stream
+					_ = s.Close()
+					return true, nil
+				case err == errEndOfStream && s.draining != nil:
+					// the server ended the stream or the drain deadline passed, CloseGraceful ends the stream without error
+					_ = s.Close()
+					return true, nil
+				case (err == errEndOfStream || isPollError(err)) && s.reconnect != nil && !s.closed:
+					if err := s.reconnectStream(ctx); err != nil {
+						_ = s.closeWithError(err)
+						return true, err
+					}
+					return false, ErrReconnected
+				case err == errEndOfStream:
+					_ = s.closeWithError(ErrUnexpectedEndOfStream)
+					return true, ErrUnexpectedEndOfStream
+				case isPollError(err):
+					err = errors.Unwrap(err)
+					_ = s.closeWithError(err)
+					return true, err
+				default:
+					_ = s.closeWithError(err)
+					return true, err
+				}
+			}
+			if len(bytes.TrimSpace(s.buf.Bytes())) != 0 {
+				break
+			}
+			// empty frames are keepalives sent by the server
+			if s.onHeartbeat != nil {
+				s.onHeartbeat()
+			}
+		}
+		if s.jsonPatch {
+			if err := s.applyPatchFrame(); err != nil {
+				_ = s.closeWithError(err)
+				return true, err
+			}
+		}
+		errs, extensions, err := decode(s.buf.Bytes())
+		if err != nil {
+			err = fmt.Errorf("error reading JSON: %w", err)
+			_ = s.closeWithError(err)
+			return true, err
+		}
+		if hasJSONValue(extensions) && s.onExtensions != nil {
+			s.onExtensions(extensions)
+		}
+		if s.span != nil {
+			s.span.AddEvent("message")
+		}
+		if s.observer != nil {
+			s.observer.ObserveStreamMessage(s.operation, s.path)
+		}
+		s.messages++
+		s.emit(StreamMessageReceived{N: s.messages})
+		if len(errs) == 0 && s.dedupe != nil && s.dedupe(s.buf.Bytes()) {
+			continue
+		}
+		if len(errs) != 0 {
+			// error frames don't end the stream, the caller decides whether to continue reading
+			return false, newGraphQLError(errs, s.errorCodes)
+		}
+		return false, nil
+	}
+}
+
+// decodeFrame decodes a message, decoding the buffered frame directly avoids allocating a Decoder per message
+func decodeFrame[T any](codec Codec, strict bool, frame []byte, envelope *responseEnvelope[T]) error {
+	if strict {
+		return newDecoder(codec, bytes.NewReader(frame), true).Decode(envelope)
+	}
+	return codec.Unmarshal(frame, envelope)
+}
+
+// contextError returns the error Next reports once ctx is done, cancellation ends the stream without error
+func contextError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("stream timed out: %w", ctx.Err())
+	}
+	return nil
+}
+
+func isEventStream(header http.Header) bool {
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	return err == nil && mediaType == "text/event-stream"
+}
+
+// reconnectStream re-establishes the stream after the connection dropped
+func (s *Stream[Response]) reconnectStream(ctx context.Context) error {
+	_ = s.closeBody()
+	var (
+		lastErr error
+	)
+	for attempt := 1; attempt <= s.reconnect.maxRetries; attempt++ {
+		wait := defaultBackoff
+		if s.reconnect.backoff != nil {
+			wait = s.reconnect.backoff
+		}
+		timer := time.NewTimer(wait(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-s.ctx.Done():
+			timer.Stop()
+			return s.ctx.Err()
+		case <-timer.C:
+		}
+		if s.logger != nil {
+			s.logger.LogAttrs(s.ctx, slog.LevelWarn, "reconnecting stream", slog.Int("attempt", attempt))
+		}
+		s.emit(StreamReconnecting{Attempt: attempt})
+		res, cancel, err := s.open(s.ctx)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		s.setResponse(res, cancel)
+		if s.span != nil {
+			s.span.AddEvent("reconnected")
+		}
+		s.emit(StreamConnected{})
+		return nil
+	}
+	return fmt.Errorf("reconnecting stream failed after %d attempts: %w", s.reconnect.maxRetries, lastErr)
+}
@@ -0,0 +1,164 @@
+package execute_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+// newStreamServer serves frames as a single write and keeps the stream open until the client goes away
+func newStreamServer(t *testing.T, frames string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(frames))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestStreamCloseGracefulDrainsBufferedMessages(t *testing.T) {
+	srv := newStreamServer(t, `{"data":{"n":1}}`+"\n\n"+`{"data":{"n":2}}`+"\n\n")
+	stream, err := execute.New(srv.Client(), srv.URL).Subscribe(context.Background(), "/operations/Counter", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	ctx := context.Background()
+	if res, closed, err := stream.Next(ctx); err != nil || closed || string(*res) != `{"n":1}` {
+		t.Fatalf("unexpected first message %s, closed %v, err %v", deref(res), closed, err)
+	}
+	done, cancel := context.WithCancel(context.Background())
+	cancel()
+	stream.CloseGraceful(done)
+	if res, closed, err := stream.Next(ctx); err != nil || closed || string(*res) != `{"n":2}` {
+		t.Fatalf("expected the buffered message, got %s, closed %v, err %v", deref(res), closed, err)
+	}
+	if res, closed, err := stream.Next(ctx); err != nil || !closed || res != nil {
+		t.Fatalf("expected the stream to end, got %s, closed %v, err %v", deref(res), closed, err)
+	}
+}
+
+func TestStreamNextUnblocksMidFrame(t *testing.T) {
+	tests := []struct {
+		name    string
+		newCtx  func() (context.Context, context.CancelFunc)
+		wantErr error
+	}{
+		{name: "canceled", newCtx: func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+			return ctx, cancel
+		}},
+		{name: "deadline", newCtx: func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 50*time.Millisecond)
+		}, wantErr: context.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newStreamServer(t, `{"data":{"n":1}}`+"\n\n"+`{"data":{"n":`)
+			stream, err := execute.New(srv.Client(), srv.URL).Subscribe(context.Background(), "/operations/Counter", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer stream.Close()
+			if res, closed, err := stream.Next(context.Background()); err != nil || closed || string(*res) != `{"n":1}` {
+				t.Fatalf("unexpected first message %s, closed %v, err %v", deref(res), closed, err)
+			}
+			ctx, cancel := tt.newCtx()
+			defer cancel()
+			type next struct {
+				closed bool
+				err    error
+			}
+			done := make(chan next, 1)
+			go func() {
+				_, closed, err := stream.Next(ctx)
+				done <- next{closed, err}
+			}()
+			select {
+			case n := <-done:
+				if !n.closed || !errors.Is(n.err, tt.wantErr) {
+					t.Fatalf("expected the stream to be closed with %v, got closed %v, err %v", tt.wantErr, n.closed, n.err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Next didn't return after ctx was done")
+			}
+		})
+	}
+}
+
+func deref(res *json.RawMessage) string {
+	if res == nil {
+		return "<nil>"
+	}
+	return string(*res)
+}
+
+func BenchmarkStreamDecode(b *testing.B) {
+	type message struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	subscribe := func(b *testing.B) *execute.Stream[message] {
+		client, _ := repeatClient(`{"data":{"id":1,"name":"item 1"}}`+"\n\n", "application/json")
+		stream, err := execute.Subscribe[struct{}, message](client, context.Background(), "http://localhost:9991", "/operations/Items", nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Cleanup(func() {
+			_ = stream.Close()
+		})
+		b.ReportAllocs()
+		b.ResetTimer()
+		return stream
+	}
+	b.Run("Next", func(b *testing.B) {
+		stream := subscribe(b)
+		for i := 0; i < b.N; i++ {
+			if _, closed, err := stream.Next(context.Background()); err != nil || closed {
+				b.Fatalf("closed %v, err %v", closed, err)
+			}
+		}
+	})
+	b.Run("NextInto", func(b *testing.B) {
+		stream := subscribe(b)
+		var res message
+		for i := 0; i < b.N; i++ {
+			if closed, err := stream.NextInto(context.Background(), &res); err != nil || closed {
+				b.Fatalf("closed %v, err %v", closed, err)
+			}
+		}
+	})
+}
+
+func BenchmarkStreamReadBufferSize(b *testing.B) {
+	frame := multilineFrame(256 << 10)
+	for _, size := range []int{4 << 10, 64 << 10, 1 << 20} {
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			client, body := repeatClient(frame, "application/json")
+			stream, err := execute.New(client, "http://localhost:9991").Subscribe(context.Background(), "/operations/Items", nil, execute.WithReadBufferSize(size))
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer stream.Close()
+			b.SetBytes(int64(len(frame)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, closed, err := stream.Next(context.Background()); err != nil || closed {
+					b.Fatalf("closed %v, err %v", closed, err)
+				}
+			}
+			b.ReportMetric(float64(body.reads)/float64(b.N), "reads/op")
+		})
+	}
+}
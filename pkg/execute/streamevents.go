@@ -0,0 +1,52 @@
+package execute
+
+// StreamEvent is passed to the handler set with WithStreamEvents,
+// it's a StreamConnected, StreamMessageReceived, StreamReconnecting, StreamPollingFallback or StreamClosed
+type StreamEvent interface {
+	streamEvent()
+}
+
+// StreamConnected is emitted once the stream is established, and again after every reconnect
+type StreamConnected struct{}
+
+// StreamMessageReceived is emitted for every message, N counts the messages received so far, including those carrying errors
+type StreamMessageReceived struct {
+	N int
+}
+
+// StreamReconnecting is emitted before every attempt to re-establish a dropped stream, see WithAutoReconnect
+type StreamReconnecting struct {
+	Attempt int
+}
+
+// StreamPollingFallback is emitted before a LiveQuery falls back to polling, Err is the error the live query was rejected with,
+// see WithPollingFallback
+type StreamPollingFallback struct {
+	Err error
+}
+
+// StreamClosed is emitted once the stream ends, Err is nil if it ended cleanly
+type StreamClosed struct {
+	Err error
+}
+
+func (StreamConnected) streamEvent()       {}
+func (StreamMessageReceived) streamEvent() {}
+func (StreamReconnecting) streamEvent()    {}
+func (StreamPollingFallback) streamEvent() {}
+func (StreamClosed) streamEvent()          {}
+
+// WithStreamEvents calls handler with the lifecycle events of LiveQuery and Subscribe streams, e.g. for dashboards and alerting.
+// handler is called synchronously by Next, while no message is pending, so a slow handler delays reading
+// and eventually slows down the server too.
+func WithStreamEvents(handler func(event StreamEvent)) Option {
+	return func(o *options) {
+		o.onStreamEvent = handler
+	}
+}
+
+func (s *Stream[Response]) emit(event StreamEvent) {
+	if s.onStreamEvent != nil {
+		s.onStreamEvent(event)
+	}
+}
@@ -0,0 +1,52 @@
+package execute
+
+import (
+	"context"
+	"net/http"
+)
+
+// Operation types passed to Tracer and Observer implementations
+const (
+	OperationQuery        = "query"
+	OperationMutation     = "mutation"
+	OperationSubscription = "subscription"
+	OperationLiveQuery    = "liveQuery"
+)
+
+// Tracer creates a span per operation.
+// The package otelexecute provides an OpenTelemetry implementation in a separate module,
+// so that the OpenTelemetry dependency stays optional.
+type Tracer interface {
+	// Start starts a span for the operation, the returned context is used for the request
+	Start(ctx context.Context, operation, path string) (context.Context, Span)
+	// Inject propagates the trace context of ctx into the headers of the outgoing request
+	Inject(ctx context.Context, header http.Header)
+}
+
+// Span is started by a Tracer.
+// For LiveQuery and Subscribe the span lasts until the stream is closed and records an event per message.
+type Span interface {
+	SetStatusCode(statusCode int)
+	AddEvent(name string)
+	End(err error)
+}
+
+// WithTracer creates a span per Query, Mutate, LiveQuery and Subscribe call
+func WithTracer(tracer Tracer) Option {
+	return func(o *options) {
+		o.tracer = tracer
+	}
+}
+
+func (o *options) startSpan(ctx context.Context, operation, path string) (context.Context, Span) {
+	if o.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return o.tracer.Start(ctx, operation, path)
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetStatusCode(int) {}
+func (noopSpan) AddEvent(string)   {}
+func (noopSpan) End(error)         {}
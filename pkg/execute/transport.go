@@ -0,0 +1,114 @@
+package execute
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+)
+
+// WithTransport sends requests through rt instead of the Transport of the http.Client.
+//
+// With HTTP/2, all LiveQuery and Subscribe streams to the same host share a single connection.
+// Every stream reads its own response body, so a slow consumer only exhausts its own flow control window
+// and doesn't stall other streams, as long as the window sizes are large enough for the message rate.
+// To tune them, pass a pre-configured *http2.Transport from golang.org/x/net/http2,
+// e.g. with a larger MaxReadFrameSize, or an *http.Transport with ForceAttemptHTTP2 enabled.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = rt
+	}
+}
+
+// WithTLSConfig sends requests through a copy of http.DefaultTransport using config, e.g. with client certificates for mTLS.
+// It only has an effect when passed to New and is ignored if the http.Client passed to New has a Transport,
+// configure the TLS settings of that Transport instead.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = config
+	}
+}
+
+// tlsClient returns a copy of httpClient sending requests with config
+func tlsClient(httpClient *http.Client, config *tls.Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config.Clone()
+	client := *httpClient
+	client.Transport = transport
+	return &client
+}
+
+// WithCookieJar stores cookies set by responses in jar and sends them with later requests, including streams.
+// Pass it to New for session based auth, the session cookie set by a login Mutate is then reused by all calls of the Client.
+func WithCookieJar(jar http.CookieJar) Option {
+	return func(o *options) {
+		o.jar = jar
+	}
+}
+
+// WithRedirectPolicy sets the CheckRedirect func of the http.Client used for the call, including streams,
+// see http.Client.CheckRedirect. Use NoRedirects to disable following redirects
+// or StripHeadersOnRedirect to remove credentials before they're sent to another host.
+// By default, http.Client only drops Authorization and Cookie headers on redirects to another domain, not custom ones like API keys.
+func WithRedirectPolicy(checkRedirect func(req *http.Request, via []*http.Request) error) Option {
+	return func(o *options) {
+		o.checkRedirect = checkRedirect
+	}
+}
+
+// NoRedirects is a redirect policy for WithRedirectPolicy which doesn't follow redirects,
+// calls fail with an *APIError carrying the 3xx status code instead
+func NoRedirects(req *http.Request, via []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+// maxRedirects is the number of redirects http.Client follows by default
+const maxRedirects = 10
+
+// sensitiveHeaders are removed by StripHeadersOnRedirect in addition to the headers passed to it
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", defaultCSRFHeader}
+
+// StripHeadersOnRedirect returns a redirect policy for WithRedirectPolicy, which removes the Authorization,
+// Proxy-Authorization, Cookie and X-CSRF-Token headers as well as headers from redirected requests to a host
+// other than the one of the original request. Like the default policy, it stops after 10 redirects.
+func StripHeadersOnRedirect(headers ...string) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return errors.New("stopped after 10 redirects")
+		}
+		if req.URL.Host == via[0].URL.Host {
+			return nil
+		}
+		for _, header := range sensitiveHeaders {
+			req.Header.Del(header)
+		}
+		for _, header := range headers {
+			req.Header.Del(header)
+		}
+		return nil
+	}
+}
+
+// httpClientFor returns the http.Client for a call, options overriding its fields are applied to a copy
+func (c *Client) httpClientFor(o *options) *http.Client {
+	if o.transport == nil && o.jar == nil && o.checkRedirect == nil && c.endpoints == nil {
+		return c.httpClient
+	}
+	client := *c.httpClient
+	if o.transport != nil {
+		client.Transport = o.transport
+	}
+	if o.jar != nil {
+		client.Jar = o.jar
+	}
+	if o.checkRedirect != nil {
+		client.CheckRedirect = o.checkRedirect
+	}
+	if c.endpoints != nil {
+		next := client.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		client.Transport = &endpointTransport{pool: c.endpoints, next: next}
+	}
+	return &client
+}
@@ -0,0 +1,89 @@
+package execute_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+func TestSubscribeMultiplexedOverHTTP2(t *testing.T) {
+	const (
+		streams  = 50
+		messages = 10
+	)
+	var connections atomic.Int64
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			http.Error(w, "expected HTTP/2", http.StatusHTTPVersionNotSupported)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/operations/Stalled" {
+			// fill the buffers of a stream which is never read
+			_, _ = fmt.Fprintf(w, `{"data":{"s":%q}}`+"\n\n", strings.Repeat("x", 256<<10))
+			w.(http.Flusher).Flush()
+		}
+		for i := 1; r.URL.Path == "/operations/Counter" && i <= messages; i++ {
+			_, _ = fmt.Fprintf(w, `{"data":{"n":%d}}`+"\n\n", i)
+			w.(http.Flusher).Flush()
+		}
+		<-r.Context().Done()
+	}))
+	srv.EnableHTTP2 = true
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	c := execute.New(srv.Client(), srv.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// establish the connection first, so that all streams share it
+	stalled, err := c.Subscribe(ctx, "/operations/Stalled", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stalled.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, streams)
+	for i := 0; i < streams; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stream, err := c.Subscribe(ctx, "/operations/Counter", nil)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer stream.Close()
+			for n := 1; n <= messages; n++ {
+				res, closed, err := stream.Next(ctx)
+				if err != nil || closed || string(*res) != fmt.Sprintf(`{"n":%d}`, n) {
+					errs <- fmt.Errorf("message %d: got %s, closed %v, err %v", n, deref(res), closed, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if n := connections.Load(); n != 1 {
+		t.Errorf("expected all streams to share 1 connection, got %d", n)
+	}
+}
@@ -0,0 +1,56 @@
+package execute
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// WithResponseUnmarshaler decodes responses of mediaType, e.g. application/x-protobuf, with unmarshal instead of the Codec,
+// JSON responses are still decoded by the Codec. Such responses carry only the data, so unmarshal receives the whole body
+// and a pointer to the Response. Negotiate the content type by setting the Accept header with WithHeader.
+// It applies to Query and Mutate, not to streams.
+func WithResponseUnmarshaler(mediaType string, unmarshal func(data []byte, v any) error) Option {
+	mediaType = strings.ToLower(mediaType)
+	return func(o *options) {
+		if o.unmarshalers == nil {
+			o.unmarshalers = map[string]func(data []byte, v any) error{}
+		}
+		o.unmarshalers[mediaType] = unmarshal
+	}
+}
+
+// unmarshalerFor returns the unmarshaler registered for the Content-Type in header, or nil
+func (o *options) unmarshalerFor(header http.Header) func(data []byte, v any) error {
+	if len(o.unmarshalers) == 0 {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return nil
+	}
+	return o.unmarshalers[mediaType]
+}
+
+// decodeUnmarshaled decodes the body of res with unmarshal, json.RawMessage keeps the body for decodeInto
+func decodeUnmarshaled[Response any](result *Result[Response], res *http.Response, o *options, unmarshal func(data []byte, v any) error) (*Result[Response], error) {
+	body, err := io.ReadAll(responseBody(res, o))
+	if errors.Is(err, ErrResponseTooLarge) {
+		return nil, ErrResponseTooLarge
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+	var data Response
+	if raw, ok := any(&data).(*json.RawMessage); ok {
+		*raw = body
+	} else if err := unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+	result.Data = &data
+	return result, nil
+}
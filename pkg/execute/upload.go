@@ -0,0 +1,118 @@
+package execute
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// Upload is a file sent with MutateUpload
+type Upload struct {
+	// Path is the location of the file in the input, e.g. "file" or "files.0"
+	Path     string
+	Filename string
+	// ContentType defaults to application/octet-stream
+	ContentType string
+	Body        io.Reader
+}
+
+// MutateUpload sends a mutation with files as multipart/form-data request following the GraphQL multipart request spec.
+// The input is sent as operations field, the fields referenced by the uploads should be left empty.
+// Files are streamed instead of being buffered in memory, which is why the request is never retried or repeated,
+// also not after WithTokenRefresh refreshed the credentials for a request rejected with 401 Unauthorized.
+func MutateUpload[Input any, Response any](client *http.Client, ctx context.Context, baseURL, path string, input *Input, uploads []Upload, opts ...Option) (*Response, error) {
+	result, err := mutateUpload[Response](New(client, baseURL), ctx, path, input, uploads, newOptions(opts))
+	if result == nil {
+		return nil, err
+	}
+	return result.Data, err
+}
+
+// MutateUpload is like the package level MutateUpload, it decodes the data into response, which must be a pointer
+func (c *Client) MutateUpload(ctx context.Context, path string, input any, uploads []Upload, response any, opts ...Option) error {
+	o := c.options(opts)
+	result, err := mutateUpload[json.RawMessage](c, ctx, path, input, uploads, o)
+	return decodeInto(result, err, response, o)
+}
+
+func mutateUpload[Response any](c *Client, ctx context.Context, path string, input any, uploads []Upload, o *options) (*Result[Response], error) {
+	baseUrlWithPath, err := c.operationURL(o, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	method, err := o.mutationMethod()
+	if err != nil {
+		return nil, err
+	}
+	if err := o.validateInput(input); err != nil {
+		return nil, err
+	}
+	operations, err := o.encodeInput(input)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEncodingInput, err)
+	}
+	fileMap := make(map[string][]string, len(uploads))
+	for i, upload := range uploads {
+		fileMap[strconv.Itoa(i)] = []string{upload.Path}
+	}
+	fileMapJSON, err := o.codec.Marshal(fileMap)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding file map: %w", err)
+	}
+	return doOperation[Response](c, ctx, o, OperationMutation, path, false, "", func(ctx context.Context) (*http.Request, error) {
+		pr, pw := io.Pipe()
+		req, err := o.newStreamingRequest(ctx, method, baseUrlWithPath, pr)
+		if err != nil {
+			_ = pr.Close()
+			return nil, err
+		}
+		if err := o.setCSRFToken(req); err != nil {
+			_ = pr.Close()
+			return nil, err
+		}
+		if o.idempotencyKey != "" {
+			req.Header.Set(idempotencyKeyHeader, o.idempotencyKey)
+		}
+		mw := multipart.NewWriter(pw)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		// the http.Client closes the body when the request fails, which unblocks the writer
+		go func() {
+			_ = pw.CloseWithError(writeMultipart(mw, operations, fileMapJSON, uploads))
+		}()
+		return req, nil
+	})
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func writeMultipart(mw *multipart.Writer, operations, fileMap []byte, uploads []Upload) error {
+	if err := mw.WriteField("operations", string(operations)); err != nil {
+		return err
+	}
+	if err := mw.WriteField("map", string(fileMap)); err != nil {
+		return err
+	}
+	for i, upload := range uploads {
+		contentType := upload.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%d"; filename="%s"`, i, quoteEscaper.Replace(upload.Filename)))
+		header.Set("Content-Type", contentType)
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(part, upload.Body); err != nil {
+			return fmt.Errorf("uploading %s: %w", upload.Path, err)
+		}
+	}
+	return mw.Close()
+}
@@ -0,0 +1,41 @@
+package execute_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+func TestMutateUploadIsNotRepeatedAfterTokenRefresh(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{}}`))
+	}))
+	defer srv.Close()
+	var refreshed atomic.Int32
+	c := execute.New(srv.Client(), srv.URL, execute.WithTokenRefresh(func(ctx context.Context) error {
+		refreshed.Add(1)
+		return nil
+	}))
+	uploads := []execute.Upload{{Path: "file", Filename: "a.txt", Body: strings.NewReader("content")}}
+	err := c.MutateUpload(context.Background(), "/operations/Upload", nil, uploads, nil)
+	if !errors.Is(err, execute.ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("expected 1 request, got %d", n)
+	}
+	if n := refreshed.Load(); n != 1 {
+		t.Fatalf("expected the token to be refreshed once, got %d", n)
+	}
+}
@@ -0,0 +1,30 @@
+package execute
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// modulePath identifies this module in the build info of the binary
+const modulePath = "github.com/wundergraph/client-go"
+
+// WithUserAgent sets the User-Agent header of all requests, including streams.
+// It defaults to wundergraph-client-go/<version>, where version is the version of this module in the binary.
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) {
+		o.userAgent = userAgent
+	}
+}
+
+// defaultUserAgent reads the version once, it's missing if the module isn't a dependency, e.g. in its own tests
+var defaultUserAgent = sync.OnceValue(func() string {
+	userAgent := "wundergraph-client-go"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath && dep.Version != "" && dep.Version != "(devel)" {
+				return userAgent + "/" + dep.Version
+			}
+		}
+	}
+	return userAgent
+})
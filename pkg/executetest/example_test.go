@@ -0,0 +1,53 @@
+package executetest_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+	"github.com/wundergraph/client-go/pkg/executetest"
+)
+
+func Example_subscribe() {
+	transport := executetest.NewTransport()
+	transport.HandleFunc("/operations/Counter", func(w http.ResponseWriter, r *http.Request) {
+		stream := executetest.NewStreamWriter(w)
+		for n := 1; n <= 3; n++ {
+			_ = stream.Write(map[string]int{"count": n})
+		}
+	})
+
+	type counter struct {
+		Count int `json:"count"`
+	}
+	stream, err := execute.Subscribe[struct{}, counter](transport.Client(), context.Background(), "http://localhost:9991", "/operations/Counter", nil)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer stream.Close()
+	for {
+		res, closed, err := stream.Next(context.Background())
+		if closed || err != nil {
+			break
+		}
+		fmt.Println(res.Count)
+	}
+	// Output:
+	// 1
+	// 2
+	// 3
+}
+
+func ExampleTransport_Respond() {
+	transport := executetest.NewTransport()
+	transport.Respond("/operations/User", http.StatusOK, `{"data":{"name":"Jens"}}`)
+
+	var user json.RawMessage
+	err := execute.New(transport.Client(), "http://localhost:9991").Query(context.Background(), "/operations/User", nil, &user)
+	fmt.Println(string(user), err)
+	// Output:
+	// {"name":"Jens"} <nil>
+}
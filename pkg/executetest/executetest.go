@@ -0,0 +1,180 @@
+// Package executetest provides helpers for testing code which uses package execute without a real WunderGraph server.
+package executetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+// Transport is an http.RoundTripper which serves requests with the handlers registered for their path.
+// Handlers run in the same process, responses are streamed to the client as they are written,
+// so that they can serve LiveQuery and Subscribe streams. Requests for unknown paths are answered with 404.
+type Transport struct {
+	mu       sync.RWMutex
+	handlers map[string]http.Handler
+}
+
+func NewTransport() *Transport {
+	return &Transport{
+		handlers: map[string]http.Handler{},
+	}
+}
+
+// Client returns an *http.Client which sends all requests to t
+func (t *Transport) Client() *http.Client {
+	return &http.Client{Transport: t}
+}
+
+// Handle serves requests for path with handler, path excludes the query, e.g. "/operations/Users"
+func (t *Transport) Handle(path string, handler http.Handler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.handlers[path] = handler
+}
+
+// HandleFunc serves requests for path with f
+func (t *Transport) HandleFunc(path string, f func(w http.ResponseWriter, r *http.Request)) {
+	t.Handle(path, http.HandlerFunc(f))
+}
+
+// Respond answers every request for path with statusCode and the JSON document body
+func (t *Transport) Respond(path string, statusCode int, body string) {
+	t.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		_, _ = io.WriteString(w, body)
+	})
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.RLock()
+	handler, ok := t.handlers[req.URL.Path]
+	t.mu.RUnlock()
+	if !ok {
+		handler = http.NotFoundHandler()
+	}
+	// the request passed to RoundTrip must not be modified, handlers expect a non-nil body like on a server
+	served := req
+	if req.Body == nil {
+		served = req.Clone(req.Context())
+		served.Body = http.NoBody
+	}
+	pr, pw := io.Pipe()
+	w := &responseWriter{
+		req:    req,
+		header: http.Header{},
+		pr:     pr,
+		pw:     pw,
+		res:    make(chan *http.Response, 1),
+	}
+	go func() {
+		handler.ServeHTTP(w, served)
+		// handlers which don't write anything respond with 200
+		w.WriteHeader(http.StatusOK)
+		_ = pw.Close()
+	}()
+	select {
+	case res := <-w.res:
+		return res, nil
+	case <-req.Context().Done():
+		// unblocks the handler if it's still writing
+		_ = pr.CloseWithError(req.Context().Err())
+		return nil, req.Context().Err()
+	}
+}
+
+// responseWriter sends the response to RoundTrip once the header is written, the body is streamed through a pipe
+type responseWriter struct {
+	req    *http.Request
+	header http.Header
+	pr     *io.PipeReader
+	pw     *io.PipeWriter
+	once   sync.Once
+	res    chan *http.Response
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	w.once.Do(func() {
+		w.res <- &http.Response{
+			Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+			StatusCode:    statusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        w.header.Clone(),
+			Body:          w.pr,
+			ContentLength: -1,
+			Request:       w.req,
+		}
+	})
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.pw.Write(p)
+}
+
+// Flush sends the header, the body is unbuffered
+func (w *responseWriter) Flush() {
+	w.WriteHeader(http.StatusOK)
+}
+
+// StreamWriter writes LiveQuery and Subscribe messages using the \n\n delimited framing expected by execute.Stream
+type StreamWriter struct {
+	w http.ResponseWriter
+}
+
+// NewStreamWriter sets the Content-Type of w and sends the header
+func NewStreamWriter(w http.ResponseWriter) *StreamWriter {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	s := &StreamWriter{w: w}
+	s.flush()
+	return s
+}
+
+// Write sends data as the data field of the next message
+func (s *StreamWriter) Write(data any) error {
+	return s.writeMessage(map[string]any{"data": data})
+}
+
+// WriteErrors sends a message carrying errors, which execute.Stream.Next returns as *execute.GraphQLError
+func (s *StreamWriter) WriteErrors(errors ...execute.GraphQLErrorEntry) error {
+	return s.writeMessage(map[string]any{"errors": errors})
+}
+
+// Heartbeat sends an empty keepalive frame
+func (s *StreamWriter) Heartbeat() error {
+	if _, err := io.WriteString(s.w, "\n\n"); err != nil {
+		return err
+	}
+	s.flush()
+	return nil
+}
+
+func (s *StreamWriter) writeMessage(message any) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if _, err := s.w.Write(append(data, '\n', '\n')); err != nil {
+		return err
+	}
+	s.flush()
+	return nil
+}
+
+func (s *StreamWriter) flush() {
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
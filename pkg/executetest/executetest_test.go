@@ -0,0 +1,35 @@
+package executetest_test
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/wundergraph/client-go/pkg/executetest"
+)
+
+func TestTransportDoesNotModifyRequest(t *testing.T) {
+	transport := executetest.NewTransport()
+	transport.HandleFunc("/operations/User", func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil {
+			t.Error("expected handlers to receive a non-nil body")
+		}
+	})
+	req, err := http.NewRequest(http.MethodGet, "http://localhost:9991/operations/User", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Body = nil
+	res, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	_ = res.Body.Close()
+	if req.Body != nil {
+		t.Fatal("expected the request body to stay nil")
+	}
+	if res.Request != req {
+		t.Fatal("expected the response to reference the request")
+	}
+}
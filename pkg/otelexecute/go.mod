@@ -0,0 +1,29 @@
+module github.com/wundergraph/client-go/pkg/otelexecute
+
+// go.opentelemetry.io/otel v1.46.0 requires go 1.25.0, the root module keeps supporting go 1.21
+// for users who don't need OpenTelemetry
+go 1.25.0
+
+// v0.1.0 is the first release of the root module with execute.Tracer, the root module is tagged v0.1.0
+// before this module is tagged pkg/otelexecute/v0.1.0, so that consumers never depend on an untagged commit
+require (
+	github.com/wundergraph/client-go v0.1.0
+	go.opentelemetry.io/otel v1.46.0
+	go.opentelemetry.io/otel/sdk v1.46.0
+	go.opentelemetry.io/otel/trace v1.46.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/go-logr/logr v1.4.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	go.opentelemetry.io/auto/sdk v1.2.1 // indirect
+	go.opentelemetry.io/otel/metric v1.46.0 // indirect
+	golang.org/x/sync v0.10.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/time v0.10.0 // indirect
+)
+
+// the replace directive only applies when working on this repository, consumers use the tagged version required above
+replace github.com/wundergraph/client-go => ../..
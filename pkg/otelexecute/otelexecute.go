@@ -0,0 +1,69 @@
+// Package otelexecute implements execute.Tracer with OpenTelemetry.
+// It's a separate module, so that users of package execute aren't forced to depend on OpenTelemetry.
+package otelexecute
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+)
+
+// WithTracer creates spans with tracer and propagates them with the global propagator
+func WithTracer(tracer trace.Tracer) execute.Option {
+	return execute.WithTracer(NewTracer(tracer, otel.GetTextMapPropagator()))
+}
+
+// NewTracer returns an execute.Tracer which creates spans with tracer and injects them into requests with propagator
+func NewTracer(tracer trace.Tracer, propagator propagation.TextMapPropagator) execute.Tracer {
+	return &otelTracer{
+		tracer:     tracer,
+		propagator: propagator,
+	}
+}
+
+type otelTracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+func (t *otelTracer) Start(ctx context.Context, operation, path string) (context.Context, execute.Span) {
+	ctx, span := t.tracer.Start(ctx, operation+" "+path,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("wundergraph.operation.type", operation),
+			attribute.String("wundergraph.operation.path", path),
+		),
+	)
+	return ctx, &otelSpan{span: span}
+}
+
+func (t *otelTracer) Inject(ctx context.Context, header http.Header) {
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s *otelSpan) SetStatusCode(statusCode int) {
+	s.span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+}
+
+func (s *otelSpan) AddEvent(name string) {
+	s.span.AddEvent(name)
+}
+
+func (s *otelSpan) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
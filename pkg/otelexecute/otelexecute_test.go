@@ -0,0 +1,76 @@
+package otelexecute_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/wundergraph/client-go/pkg/execute"
+	"github.com/wundergraph/client-go/pkg/otelexecute"
+)
+
+func TestTracer(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		wantStatus codes.Code
+	}{
+		{name: "success", statusCode: http.StatusOK, wantStatus: codes.Unset},
+		{name: "error", statusCode: http.StatusInternalServerError, wantStatus: codes.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var traceparent string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				traceparent = r.Header.Get("traceparent")
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(`{"data":{}}`))
+			}))
+			defer srv.Close()
+			recorder := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			tracer := otelexecute.NewTracer(provider.Tracer("test"), propagation.TraceContext{})
+			err := execute.New(srv.Client(), srv.URL, execute.WithTracer(tracer)).Query(context.Background(), "/operations/Items", nil, nil)
+			var apiErr *execute.APIError
+			if (tt.wantStatus == codes.Error) != errors.As(err, &apiErr) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("expected 1 span, got %d", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != "query /operations/Items" || span.SpanKind() != trace.SpanKindClient {
+				t.Errorf("unexpected span %q of kind %v", span.Name(), span.SpanKind())
+			}
+			want := map[attribute.Key]attribute.Value{
+				"wundergraph.operation.type": attribute.StringValue("query"),
+				"wundergraph.operation.path": attribute.StringValue("/operations/Items"),
+				"http.response.status_code":  attribute.IntValue(tt.statusCode),
+			}
+			for _, kv := range span.Attributes() {
+				if value, ok := want[kv.Key]; ok && value == kv.Value {
+					delete(want, kv.Key)
+				}
+			}
+			if len(want) != 0 {
+				t.Errorf("missing attributes %v in %v", want, span.Attributes())
+			}
+			if span.Status().Code != tt.wantStatus {
+				t.Errorf("expected status %v, got %v", tt.wantStatus, span.Status())
+			}
+			if traceparent == "" || traceparent[3:35] != span.SpanContext().TraceID().String() {
+				t.Errorf("expected the trace to be propagated, got traceparent %q", traceparent)
+			}
+		})
+	}
+}